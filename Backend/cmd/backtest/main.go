@@ -0,0 +1,116 @@
+// Command backtest evalúa una estrategia de puntaje reproduciendo día a día el
+// historial de eventos guardado en la base de datos.
+//
+// Uso:
+//
+//	go run ./cmd/backtest -strategy momentum -from 2025-01-01 -to 2025-03-31 -horizon-days 30
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"Backend/config"
+	"Backend/repositories"
+	"Backend/services"
+)
+
+func main() {
+	strategyName := flag.String("strategy", "", "estrategia de puntaje (por defecto la configurada como default_strategy)")
+	fromFlag := flag.String("from", "", "primer día reproducido, YYYY-MM-DD (por defecto 89 días antes de -to)")
+	toFlag := flag.String("to", "", "último día reproducido, YYYY-MM-DD (por defecto un horizonte antes del último evento)")
+	horizonDays := flag.Int("horizon-days", 30, "días tras cada recomendación en los que se miden las notas siguientes")
+	topN := flag.Int("top", 0, "evaluar solo las N mejores recomendaciones de cada día (0 = todas)")
+	asJSON := flag.Bool("json", false, "imprimir el reporte completo en JSON")
+	flag.Parse()
+
+	if *horizonDays <= 0 {
+		log.Fatalf("-horizon-days debe ser mayor que 0")
+	}
+	horizon := time.Duration(*horizonDays) * 24 * time.Hour
+
+	from, err := parseDate(*fromFlag)
+	if err != nil {
+		log.Fatalf("-from inválido: %v", err)
+	}
+	to, err := parseDate(*toFlag)
+	if err != nil {
+		log.Fatalf("-to inválido: %v", err)
+	}
+
+	db, err := config.InitDB()
+	if err != nil {
+		log.Fatalf("Error initializing database: %v", err)
+	}
+
+	cfg, err := services.ReloadScoringConfig()
+	if err != nil {
+		log.Fatalf("Error loading scoring config: %v", err)
+	}
+	strategy, exists := cfg.Strategy(*strategyName)
+	if !exists {
+		log.Fatalf("Estrategia desconocida %q; disponibles: %v", *strategyName, cfg.StrategyNames())
+	}
+
+	events, err := repositories.GetRecentStocks(db, time.Time{})
+	if err != nil {
+		log.Fatalf("Error loading events: %v", err)
+	}
+	scorer, err := services.LoadBrokerScorer(db)
+	if err != nil {
+		log.Fatalf("Error loading broker weights: %v", err)
+	}
+
+	from, to = services.DefaultBacktestRange(events, horizon, from, to)
+
+	report, err := services.RunBacktest(events, services.BacktestOptions{
+		Strategy: strategy,
+		Brokers:  scorer,
+		From:     from,
+		To:       to,
+		Horizon:  horizon,
+		TopN:     *topN,
+	})
+	if err != nil {
+		log.Fatalf("Error running backtest: %v", err)
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			log.Fatalf("Error encoding report: %v", err)
+		}
+		return
+	}
+	printReport(report)
+}
+
+func parseDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.DateOnly, value)
+}
+
+// printReport imprime el resumen del backtest como tabla
+func printReport(report *services.BacktestReport) {
+	fmt.Printf("Estrategia %s, del %s al %s, horizonte de %.0f días\n",
+		report.Strategy, report.From, report.To, report.HorizonHours/24)
+	fmt.Printf("Eventos: %d  Recomendaciones: %d  Evaluadas: %d  Correlación puntaje/notas siguientes: %.3f\n\n",
+		report.Events, report.Recommendations, report.Evaluated, report.Correlation)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "Tramo\tRecomendaciones\tEvaluadas\tPositivas\tNegativas\tCambio objetivo medio\t")
+	for _, band := range report.Bands {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%.1f%%\t%.1f%%\t%+.2f%%\t\n",
+			band.Label, band.Recommendations, band.Evaluated,
+			band.PositiveRate*100, band.NegativeRate*100, band.AvgTargetChangePct)
+	}
+	w.Flush()
+}
@@ -76,7 +76,7 @@ func InitDB() (*gorm.DB, error) {
 		sqlDB.SetConnMaxLifetime(time.Hour)
 
 		// Auto-migrar el esquema de la base de datos
-		if err := db.AutoMigrate(&models.Stock{}); err != nil {
+		if err := db.AutoMigrate(&models.Stock{}, &models.IngestionJob{}, &models.QuarantinedStock{}, &models.IngestionState{}, &models.BrokerWeight{}, &models.RecommendationSnapshot{}, &models.RecommendationSnapshotItem{}, &models.User{}, &models.RefreshToken{}, &models.APIKey{}); err != nil {
 			initErr = fmt.Errorf("error al migrar la base de datos: %v", err)
 			return
 		}
@@ -1,7 +1,12 @@
 package config
 
 import (
+	"errors"
+	"io"
+	"math/rand/v2"
 	"net/http"
+	"os"
+	"strconv"
 	"sync"
 	"time"
 )
@@ -11,19 +16,59 @@ var (
 	httpOnce   sync.Once
 )
 
-// InitHTTPClient inicializa un cliente HTTP compartido con configuraciones seguras
+// ErrCircuitOpen indica que el circuit breaker del host está abierto y la
+// petición no se envió
+var ErrCircuitOpen = errors.New("circuit breaker abierto: el servicio upstream está fallando")
+
+// HTTPClientConfig contiene las políticas de reintento del cliente HTTP compartido
+type HTTPClientConfig struct {
+	// Timeout es el tiempo máximo de una petición incluidos los reintentos
+	Timeout time.Duration
+	// AttemptTimeout es el tiempo máximo de espera de la respuesta de cada intento
+	AttemptTimeout time.Duration
+	MaxRetries     int
+	BaseDelay      time.Duration
+	MaxDelay       time.Duration
+	// MaxRetryAfter limita la espera solicitada por el servidor con Retry-After
+	MaxRetryAfter    time.Duration
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+}
+
+// LoadHTTPClientConfig lee las políticas del cliente HTTP desde las variables de entorno
+func LoadHTTPClientConfig() HTTPClientConfig {
+	return HTTPClientConfig{
+		Timeout:          envDuration("HTTP_TIMEOUT", 2*time.Minute),
+		AttemptTimeout:   envDuration("HTTP_ATTEMPT_TIMEOUT", 30*time.Second),
+		MaxRetries:       envInt("HTTP_MAX_RETRIES", 3),
+		BaseDelay:        envDuration("HTTP_RETRY_BASE_DELAY", 500*time.Millisecond),
+		MaxDelay:         envDuration("HTTP_RETRY_MAX_DELAY", 10*time.Second),
+		MaxRetryAfter:    envDuration("HTTP_MAX_RETRY_AFTER", time.Minute),
+		BreakerThreshold: envInt("HTTP_BREAKER_THRESHOLD", 5),
+		BreakerCooldown:  envDuration("HTTP_BREAKER_COOLDOWN", 30*time.Second),
+	}
+}
+
+// InitHTTPClient inicializa un cliente HTTP compartido con configuraciones seguras,
+// reintentos con backoff exponencial y un circuit breaker por host
 func InitHTTPClient() *http.Client {
 	httpOnce.Do(func() {
+		cfg := LoadHTTPClientConfig()
 		httpClient = &http.Client{
-			Timeout: 30 * time.Second,
-			Transport: &http.Transport{
-				MaxIdleConns:          100,
-				MaxIdleConnsPerHost:   30,
-				IdleConnTimeout:       90 * time.Second,
-				TLSHandshakeTimeout:   10 * time.Second,
-				ExpectContinueTimeout: 1 * time.Second,
-				DisableKeepAlives:     false,
-				ForceAttemptHTTP2:     true,
+			Timeout: cfg.Timeout,
+			Transport: &retryTransport{
+				cfg: cfg,
+				base: &http.Transport{
+					MaxIdleConns:          100,
+					MaxIdleConnsPerHost:   30,
+					IdleConnTimeout:       90 * time.Second,
+					TLSHandshakeTimeout:   10 * time.Second,
+					ResponseHeaderTimeout: cfg.AttemptTimeout,
+					ExpectContinueTimeout: 1 * time.Second,
+					DisableKeepAlives:     false,
+					ForceAttemptHTTP2:     true,
+				},
+				breakers: make(map[string]*circuitBreaker),
 			},
 		}
 	})
@@ -37,3 +82,203 @@ func GetHTTPClient() *http.Client {
 	}
 	return httpClient
 }
+
+// retryTransport reintenta las peticiones idempotentes fallidas por errores de
+// red, 5xx o 429
+type retryTransport struct {
+	cfg  HTTPClientConfig
+	base http.RoundTripper
+
+	mu       sync.Mutex
+	breakers map[string]*circuitBreaker
+}
+
+// isIdempotent indica si se puede reintentar una petición sin riesgo de
+// repetir su efecto: métodos idempotentes o peticiones con Idempotency-Key
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get("Idempotency-Key") != "" || req.Header.Get("X-Idempotency-Key") != ""
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	breaker := t.breaker(req.URL.Host)
+	allowed, probe := breaker.allow()
+	if !allowed {
+		return nil, ErrCircuitOpen
+	}
+	if probe {
+		// Si la petición de prueba termina sin registrar un resultado
+		// (cancelación o error local) se libera para permitir otra
+		defer breaker.release()
+	}
+
+	for attempt := 0; ; attempt++ {
+		attemptReq := req
+		if attempt > 0 && req.Body != nil && req.Body != http.NoBody {
+			// Solo se puede reintentar si el cuerpo puede regenerarse
+			if req.GetBody == nil {
+				return nil, errors.New("no se puede reintentar una petición sin GetBody")
+			}
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			attemptReq = req.Clone(req.Context())
+			attemptReq.Body = body
+		}
+
+		resp, err := t.base.RoundTrip(attemptReq)
+
+		// Si el llamador canceló la petición no se reintenta
+		if req.Context().Err() != nil {
+			return resp, err
+		}
+
+		if !isRetryable(resp, err) || !isIdempotent(req) {
+			breaker.record(resp != nil && resp.StatusCode < 500)
+			return resp, err
+		}
+
+		if attempt >= t.cfg.MaxRetries {
+			// Un 429 indica un límite de uso, no un fallo del servicio
+			breaker.record(resp != nil && resp.StatusCode == http.StatusTooManyRequests)
+			return resp, err
+		}
+
+		delay := t.backoff(attempt)
+		if resp != nil {
+			if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+				delay = min(retryAfter, t.cfg.MaxRetryAfter)
+			}
+			// Liberar la conexión antes de reintentar
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (t *retryTransport) breaker(host string) *circuitBreaker {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	b, exists := t.breakers[host]
+	if !exists {
+		b = &circuitBreaker{threshold: t.cfg.BreakerThreshold, cooldown: t.cfg.BreakerCooldown}
+		t.breakers[host] = b
+	}
+	return b
+}
+
+// backoff calcula la espera exponencial con jitter completo para un intento
+func (t *retryTransport) backoff(attempt int) time.Duration {
+	delay := t.cfg.BaseDelay << attempt
+	if delay <= 0 || delay > t.cfg.MaxDelay {
+		delay = t.cfg.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return rand.N(delay) + 1
+}
+
+func isRetryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 && resp.StatusCode != http.StatusNotImplemented
+}
+
+// parseRetryAfter interpreta la cabecera Retry-After en segundos o como fecha HTTP
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(date); wait > 0 {
+			return wait, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// circuitBreaker deja de enviar peticiones a un host tras varios fallos
+// consecutivos. Pasado el cooldown permite una petición de prueba.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+// allow indica si la petición puede enviarse y si es la petición de prueba
+// tras el cooldown
+func (b *circuitBreaker) allow() (allowed bool, probe bool) {
+	if b.threshold <= 0 {
+		return true, false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.threshold {
+		return true, false
+	}
+	if time.Now().Before(b.openUntil) || b.probing {
+		return false, false
+	}
+	b.probing = true
+	return true, true
+}
+
+func (b *circuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+func (b *circuitBreaker) record(success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	if success {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openUntil = time.Now().Add(b.cooldown)
+	}
+}
+
+func envDuration(key string, fallback time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || value < 0 {
+		return fallback
+	}
+	return value
+}
+
+func envInt(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value < 0 {
+		return fallback
+	}
+	return value
+}
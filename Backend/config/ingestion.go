@@ -0,0 +1,45 @@
+package config
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"Backend/models"
+)
+
+// IngestionConfig contiene la configuración de la ingesta de stocks
+type IngestionConfig struct {
+	// AdvisoryLock habilita un advisory lock de PostgreSQL para que solo una
+	// réplica ejecute la ingesta a la vez
+	AdvisoryLock bool
+	// PageDelay es la pausa entre páginas para no sobrecargar la fuente
+	PageDelay time.Duration
+	// PageBudget es el máximo de páginas por ejecución (0 = sin límite)
+	PageBudget int
+	// StaleJobAfter es el tiempo sin progreso tras el cual un job en running se
+	// da por interrumpido cuando no se usa el advisory lock
+	StaleJobAfter time.Duration
+	// Mode es el modo de ingesta por defecto: auto, backfill o incremental
+	Mode string
+	// SnapshotRetentionDays es la antigüedad máxima en días de los snapshots de
+	// recomendaciones que se conservan tras cada ingesta (0 = todos)
+	SnapshotRetentionDays int
+}
+
+// LoadIngestionConfig lee la configuración de la ingesta desde las variables de entorno
+func LoadIngestionConfig() *IngestionConfig {
+	mode := strings.ToLower(strings.TrimSpace(os.Getenv("INGESTION_MODE")))
+	if mode == "" {
+		mode = models.IngestionModeAuto
+	}
+
+	return &IngestionConfig{
+		AdvisoryLock:          strings.EqualFold(os.Getenv("INGESTION_ADVISORY_LOCK"), "true"),
+		PageDelay:             envDuration("INGESTION_PAGE_DELAY", time.Second),
+		PageBudget:            envInt("INGESTION_PAGE_BUDGET", 20),
+		StaleJobAfter:         envDuration("INGESTION_STALE_JOB_AFTER", time.Hour),
+		Mode:                  mode,
+		SnapshotRetentionDays: envInt("RECOMMENDATION_SNAPSHOT_RETENTION_DAYS", 90),
+	}
+}
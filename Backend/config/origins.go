@@ -0,0 +1,64 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// originPattern es un origen permitido. Un host que empieza con "*." acepta
+// cualquier subdominio, a cualquier profundidad, pero no el dominio mismo.
+type originPattern struct {
+	scheme string
+	host   string
+	port   string
+	// wildcard indica que host es un sufijo (".example.com")
+	wildcard bool
+}
+
+// parseOriginPattern interpreta un origen como "https://app.example.com",
+// "https://*.example.com" o "http://localhost:5173"
+func parseOriginPattern(value string) (originPattern, error) {
+	scheme, rest, found := strings.Cut(strings.ToLower(strings.TrimSpace(value)), "://")
+	if !found || (scheme != "http" && scheme != "https") || rest == "" || strings.ContainsAny(rest, "/?#@") {
+		return originPattern{}, fmt.Errorf("origen inválido %q: se espera <http|https>://<host>[:puerto]", value)
+	}
+
+	host, port := rest, ""
+	if i := strings.LastIndex(rest, ":"); i >= 0 && !strings.HasSuffix(rest, "]") {
+		host, port = rest[:i], rest[i+1:]
+	}
+	pattern := originPattern{scheme: scheme, host: strings.Trim(host, "[]"), port: port}
+	if suffix, ok := strings.CutPrefix(pattern.host, "*."); ok {
+		if suffix == "" || strings.Contains(suffix, "*") {
+			return originPattern{}, fmt.Errorf("origen inválido %q", value)
+		}
+		pattern.host = "." + suffix
+		pattern.wildcard = true
+	} else if strings.Contains(pattern.host, "*") {
+		return originPattern{}, fmt.Errorf("origen inválido %q: el comodín solo puede ir al inicio del host (*.example.com)", value)
+	}
+	return pattern, nil
+}
+
+// matches indica si un origen enviado por el navegador coincide con el patrón
+func (p originPattern) matches(origin *url.URL) bool {
+	if origin.Scheme != p.scheme || origin.Port() != p.port {
+		return false
+	}
+	host := strings.ToLower(origin.Hostname())
+	if p.wildcard {
+		return strings.HasSuffix(host, p.host) && len(host) > len(p.host)
+	}
+	return host == p.host
+}
+
+// parseOrigin interpreta la cabecera Origin; solo acepta scheme y host
+func parseOrigin(origin string) (*url.URL, bool) {
+	parsed, err := url.Parse(origin)
+	if err != nil || parsed.Host == "" || parsed.User != nil || (parsed.Path != "" && parsed.Path != "/") || parsed.RawQuery != "" || parsed.Fragment != "" {
+		return nil, false
+	}
+	parsed.Scheme = strings.ToLower(parsed.Scheme)
+	return parsed, true
+}
@@ -0,0 +1,112 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Grupos de rutas con límite propio
+const (
+	// RateLimitGroupAuth son las rutas de login y renovación de tokens
+	RateLimitGroupAuth = "auth"
+	// RateLimitGroupPublic son las consultas que no requieren autenticación
+	RateLimitGroupPublic = "public"
+	// RateLimitGroupAuthenticated son las rutas que requieren un token
+	RateLimitGroupAuthenticated = "authenticated"
+	// RateLimitGroupAdmin son las rutas de administración
+	RateLimitGroupAdmin = "admin"
+)
+
+// RateLimitRule permite Requests peticiones cada Per; las peticiones se
+// recuperan de forma continua (token bucket) y se pueden agotar de golpe.
+// Requests en 0 desactiva el límite.
+type RateLimitRule struct {
+	Requests int
+	Per      time.Duration
+}
+
+// Disabled indica si la regla no limita
+func (r RateLimitRule) Disabled() bool {
+	return r.Requests <= 0 || r.Per <= 0
+}
+
+// String retorna la regla con el formato de las variables de entorno
+func (r RateLimitRule) String() string {
+	if r.Disabled() {
+		return "off"
+	}
+	return fmt.Sprintf("%d/%s", r.Requests, r.Per)
+}
+
+// RateLimitConfig contiene la configuración del rate limiting
+type RateLimitConfig struct {
+	// Global limita todas las peticiones de cada IP
+	Global RateLimitRule
+	// Groups limita cada grupo de rutas por cliente: el usuario autenticado o,
+	// si no hay, la IP
+	Groups map[string]RateLimitRule
+	// TrustedProxies son los proxies cuyas cabeceras X-Forwarded-For se usan
+	// para obtener la IP del cliente; vacío usa la IP de la conexión
+	TrustedProxies []string
+}
+
+// LoadRateLimitConfig lee la configuración del rate limiting desde las
+// variables de entorno. Las reglas tienen el formato "<peticiones>/<duración>"
+// (p.ej. "60/1m"); "off" desactiva una regla.
+func LoadRateLimitConfig() *RateLimitConfig {
+	var proxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+
+	return &RateLimitConfig{
+		Global: envRateLimit("RATE_LIMIT_GLOBAL", RateLimitRule{Requests: 300, Per: time.Minute}),
+		Groups: map[string]RateLimitRule{
+			RateLimitGroupAuth:          envRateLimit("RATE_LIMIT_AUTH", RateLimitRule{Requests: 10, Per: time.Minute}),
+			RateLimitGroupPublic:        envRateLimit("RATE_LIMIT_PUBLIC", RateLimitRule{Requests: 60, Per: time.Minute}),
+			RateLimitGroupAuthenticated: envRateLimit("RATE_LIMIT_AUTHENTICATED", RateLimitRule{Requests: 120, Per: time.Minute}),
+			RateLimitGroupAdmin:         envRateLimit("RATE_LIMIT_ADMIN", RateLimitRule{Requests: 30, Per: time.Minute}),
+		},
+		TrustedProxies: proxies,
+	}
+}
+
+// ParseRateLimitRule interpreta una regla "<peticiones>/<duración>" u "off"
+func ParseRateLimitRule(value string) (RateLimitRule, error) {
+	value = strings.TrimSpace(value)
+	if strings.EqualFold(value, "off") || value == "0" {
+		return RateLimitRule{}, nil
+	}
+
+	requests, per, found := strings.Cut(value, "/")
+	if !found {
+		return RateLimitRule{}, fmt.Errorf("regla de rate limit inválida %q: se espera <peticiones>/<duración>", value)
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(requests))
+	if err != nil || count < 0 {
+		return RateLimitRule{}, fmt.Errorf("regla de rate limit inválida %q: peticiones debe ser un entero positivo", value)
+	}
+	duration, err := time.ParseDuration(strings.TrimSpace(per))
+	if err != nil || duration <= 0 {
+		return RateLimitRule{}, fmt.Errorf("regla de rate limit inválida %q: duración inválida", value)
+	}
+	return RateLimitRule{Requests: count, Per: duration}, nil
+}
+
+func envRateLimit(key string, fallback RateLimitRule) RateLimitRule {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	rule, err := ParseRateLimitRule(value)
+	if err != nil {
+		LogError(err, "RateLimitConfig")
+		return fallback
+	}
+	return rule
+}
@@ -0,0 +1,37 @@
+package config
+
+import (
+	"os"
+	"strings"
+	"time"
+)
+
+// SchedulerConfig contiene la configuración de la ingesta programada
+type SchedulerConfig struct {
+	Enabled    bool
+	Schedule   string
+	Jitter     time.Duration
+	RunOnStart bool
+}
+
+// LoadSchedulerConfig lee la configuración del scheduler desde las variables de entorno.
+// INGESTION_SCHEDULE acepta "@every <duración>", "@hourly", "@daily" o una expresión
+// cron de cinco campos; "off" desactiva la ingesta programada.
+func LoadSchedulerConfig() *SchedulerConfig {
+	schedule := strings.TrimSpace(os.Getenv("INGESTION_SCHEDULE"))
+	if schedule == "" {
+		schedule = "@every 1h"
+	}
+
+	jitter, err := time.ParseDuration(os.Getenv("INGESTION_JITTER"))
+	if err != nil || jitter < 0 {
+		jitter = 0
+	}
+
+	return &SchedulerConfig{
+		Enabled:    !strings.EqualFold(schedule, "off"),
+		Schedule:   schedule,
+		Jitter:     jitter,
+		RunOnStart: !strings.EqualFold(os.Getenv("INGESTION_RUN_ON_START"), "false"),
+	}
+}
@@ -3,20 +3,62 @@ package config
 import (
 	"crypto/rand"
 	"encoding/base64"
+	"fmt"
 	"os"
+	"slices"
+	"strings"
 	"time"
 )
 
+// Entornos de ejecución; definen los valores por defecto de CORS y cabeceras
+const (
+	EnvironmentDevelopment = "development"
+	EnvironmentProduction  = "production"
+)
+
 // SecurityConfig contiene configuraciones de seguridad
 type SecurityConfig struct {
-	JWTSecret      string
-	TokenDuration  time.Duration
+	// Environment es el entorno de ejecución (APP_ENV)
+	Environment string
+	JWTSecret   string
+	// TokenDuration es la vigencia de los access tokens
+	TokenDuration time.Duration
+	// RefreshTokenDuration es la vigencia de los refresh tokens
+	RefreshTokenDuration time.Duration
+	// AdminUsername y AdminPassword crean el primer usuario al iniciar con la
+	// tabla de usuarios vacía
+	AdminUsername string
+	AdminPassword string
+	// AllowedOrigins son los orígenes permitidos; "https://*.example.com"
+	// acepta cualquier subdominio y "*" cualquier origen
 	AllowedOrigins []string
+	AllowedMethods []string
+	// AllowCredentials indica si CORS permite credenciales; es false con "*"
+	// en AllowedOrigins, ya que cualquier sitio podría hacer peticiones
+	// autenticadas en nombre del usuario
+	AllowCredentials bool
+	// ContentSecurityPolicy es la cabecera CSP; vacío no la envía
+	ContentSecurityPolicy string
+	// HSTSMaxAge es la vigencia de HSTS, que solo se envía en peticiones
+	// HTTPS; 0 no la envía
+	HSTSMaxAge            time.Duration
+	HSTSIncludeSubdomains bool
+	// FrameOptions es la cabecera X-Frame-Options; vacío no la envía
+	FrameOptions string
+
+	allowAllOrigins bool
+	originPatterns  []originPattern
 }
 
 var securityConfig *SecurityConfig
 
-// InitSecurityConfig inicializa las configuraciones de seguridad
+// apiMethods son los métodos HTTP que usan las rutas de la API
+var apiMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE"}
+
+// InitSecurityConfig inicializa las configuraciones de seguridad. Los valores
+// por defecto dependen de APP_ENV (development por defecto): en development se
+// permite el frontend local y no se envía HSTS; en production no hay orígenes
+// por defecto y HSTS dura un año.
 func InitSecurityConfig() (*SecurityConfig, error) {
 	if securityConfig != nil {
 		return securityConfig, nil
@@ -30,19 +72,103 @@ func InitSecurityConfig() (*SecurityConfig, error) {
 			return nil, err
 		}
 		jwtSecret = secret
+		LogInfo("JWT_SECRET no definido: se usa un secreto aleatorio y los tokens dejan de valer al reiniciar", "SecurityConfig")
+	}
+
+	environment := strings.ToLower(strings.TrimSpace(os.Getenv("APP_ENV")))
+	if environment == "" {
+		environment = EnvironmentDevelopment
+	}
+	if environment != EnvironmentDevelopment && environment != EnvironmentProduction {
+		return nil, fmt.Errorf("APP_ENV inválido %q: se espera %s o %s", environment, EnvironmentDevelopment, EnvironmentProduction)
 	}
 
-	securityConfig = &SecurityConfig{
-		JWTSecret:     jwtSecret,
-		TokenDuration: 24 * time.Hour,
-		AllowedOrigins: []string{
+	cfg := &SecurityConfig{
+		Environment:           environment,
+		JWTSecret:             jwtSecret,
+		TokenDuration:         envDuration("JWT_TOKEN_DURATION", 15*time.Minute),
+		RefreshTokenDuration:  envDuration("JWT_REFRESH_TOKEN_DURATION", 7*24*time.Hour),
+		AdminUsername:         os.Getenv("ADMIN_USERNAME"),
+		AdminPassword:         os.Getenv("ADMIN_PASSWORD"),
+		AllowedMethods:        envList("CORS_ALLOWED_METHODS", []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}),
+		ContentSecurityPolicy: envString("SECURITY_CSP", "default-src 'self'"),
+		HSTSIncludeSubdomains: !strings.EqualFold(os.Getenv("SECURITY_HSTS_INCLUDE_SUBDOMAINS"), "false"),
+		FrameOptions:          envString("SECURITY_FRAME_OPTIONS", "DENY"),
+	}
+	if environment == EnvironmentProduction {
+		cfg.AllowedOrigins = envList("CORS_ALLOWED_ORIGINS", nil)
+		cfg.HSTSMaxAge = envDuration("SECURITY_HSTS_MAX_AGE", 365*24*time.Hour)
+	} else {
+		cfg.AllowedOrigins = envList("CORS_ALLOWED_ORIGINS", []string{
 			"http://localhost:5173", // Frontend local
-		},
+		})
+		cfg.HSTSMaxAge = envDuration("SECURITY_HSTS_MAX_AGE", 0)
+	}
+	for i, method := range cfg.AllowedMethods {
+		cfg.AllowedMethods[i] = strings.ToUpper(method)
+	}
+	// Sin alguno de los métodos que usa la API el navegador rechaza el
+	// preflight de sus rutas (p.ej. PATCH /admin/users/:id)
+	for _, method := range apiMethods {
+		if !slices.Contains(cfg.AllowedMethods, method) {
+			LogInfo("CORS_ALLOWED_METHODS no incluye "+method+": los navegadores no podrán usar las rutas con ese método", "SecurityConfig")
+		}
+	}
+
+	for _, origin := range cfg.AllowedOrigins {
+		if origin == "*" {
+			cfg.allowAllOrigins = true
+			continue
+		}
+		pattern, err := parseOriginPattern(origin)
+		if err != nil {
+			return nil, err
+		}
+		cfg.originPatterns = append(cfg.originPatterns, pattern)
+	}
+	cfg.AllowCredentials = !cfg.allowAllOrigins
+	if cfg.allowAllOrigins {
+		LogInfo("CORS_ALLOWED_ORIGINS incluye \"*\": se aceptan todos los orígenes pero CORS no permite credenciales", "SecurityConfig")
+	}
+	if len(cfg.AllowedOrigins) == 0 {
+		LogInfo("CORS_ALLOWED_ORIGINS vacío: se rechazan las peticiones de navegadores con cabecera Origin", "SecurityConfig")
 	}
 
+	securityConfig = cfg
 	return securityConfig, nil
 }
 
+// IsOriginAllowed indica si un origen está permitido. Lo usan tanto
+// SecurityMiddleware como la configuración de CORS.
+func (c *SecurityConfig) IsOriginAllowed(origin string) bool {
+	if c.allowAllOrigins {
+		return true
+	}
+	parsed, ok := parseOrigin(origin)
+	if !ok {
+		return false
+	}
+	for _, pattern := range c.originPatterns {
+		if pattern.matches(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// HSTSHeader retorna el valor de Strict-Transport-Security; vacío si HSTS
+// está desactivado
+func (c *SecurityConfig) HSTSHeader() string {
+	if c.HSTSMaxAge <= 0 {
+		return ""
+	}
+	value := fmt.Sprintf("max-age=%d", int64(c.HSTSMaxAge.Seconds()))
+	if c.HSTSIncludeSubdomains {
+		value += "; includeSubDomains"
+	}
+	return value
+}
+
 // generateSecureToken genera un token seguro
 func generateSecureToken(length int) (string, error) {
 	bytes := make([]byte, length)
@@ -59,3 +185,33 @@ func GetSecurityConfig() *SecurityConfig {
 	}
 	return securityConfig
 }
+
+// envList lee una lista separada por comas; "none" la deja vacía
+func envList(key string, fallback []string) []string {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return fallback
+	}
+	if strings.EqualFold(value, "none") {
+		return nil
+	}
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// envString lee una cadena; "off" la deja vacía
+func envString(key, fallback string) string {
+	value, exists := os.LookupEnv(key)
+	if !exists || strings.TrimSpace(value) == "" {
+		return fallback
+	}
+	if strings.EqualFold(strings.TrimSpace(value), "off") {
+		return ""
+	}
+	return strings.TrimSpace(value)
+}
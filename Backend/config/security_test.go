@@ -0,0 +1,78 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// loadTestSecurityConfig reinicia la configuración cacheada y la carga con los
+// orígenes indicados
+func loadTestSecurityConfig(t *testing.T, origins string) *SecurityConfig {
+	t.Helper()
+	InitLogger()
+	t.Setenv("APP_ENV", EnvironmentProduction)
+	t.Setenv("JWT_SECRET", "secreto-de-prueba")
+	t.Setenv("CORS_ALLOWED_ORIGINS", origins)
+	securityConfig = nil
+	t.Cleanup(func() { securityConfig = nil })
+
+	cfg, err := InitSecurityConfig()
+	require.NoError(t, err)
+	return cfg
+}
+
+func TestIsOriginAllowedWildcardDepth(t *testing.T) {
+	cfg := loadTestSecurityConfig(t, "https://*.example.com")
+
+	assert.True(t, cfg.IsOriginAllowed("https://app.example.com"))
+	assert.True(t, cfg.IsOriginAllowed("https://a.b.example.com"))
+	assert.True(t, cfg.IsOriginAllowed("https://APP.Example.com"))
+	// El comodín no incluye el dominio mismo ni dominios que solo terminan igual
+	assert.False(t, cfg.IsOriginAllowed("https://example.com"))
+	assert.False(t, cfg.IsOriginAllowed("https://evilexample.com"))
+	assert.False(t, cfg.IsOriginAllowed("https://example.com.evil.com"))
+}
+
+func TestIsOriginAllowedPort(t *testing.T) {
+	cfg := loadTestSecurityConfig(t, "http://localhost:5173,https://*.example.com")
+
+	assert.True(t, cfg.IsOriginAllowed("http://localhost:5173"))
+	assert.False(t, cfg.IsOriginAllowed("http://localhost"))
+	assert.False(t, cfg.IsOriginAllowed("http://localhost:8080"))
+	assert.False(t, cfg.IsOriginAllowed("https://app.example.com:8443"))
+}
+
+func TestIsOriginAllowedScheme(t *testing.T) {
+	cfg := loadTestSecurityConfig(t, "https://app.example.com")
+
+	assert.True(t, cfg.IsOriginAllowed("https://app.example.com"))
+	assert.True(t, cfg.IsOriginAllowed("HTTPS://app.example.com"))
+	assert.False(t, cfg.IsOriginAllowed("http://app.example.com"))
+	assert.False(t, cfg.IsOriginAllowed("wss://app.example.com"))
+}
+
+func TestIsOriginAllowedRejectsMalformed(t *testing.T) {
+	cfg := loadTestSecurityConfig(t, "https://app.example.com")
+
+	for _, origin := range []string{
+		"",
+		"null",
+		"app.example.com",
+		"https://user@app.example.com",
+		"https://app.example.com/path",
+		"https://app.example.com?x=1",
+	} {
+		assert.False(t, cfg.IsOriginAllowed(origin), "origen %q", origin)
+	}
+}
+
+func TestAllowAllOriginsDisablesCredentials(t *testing.T) {
+	cfg := loadTestSecurityConfig(t, "*")
+	assert.True(t, cfg.IsOriginAllowed("https://cualquiera.com"))
+	assert.False(t, cfg.AllowCredentials)
+
+	cfg = loadTestSecurityConfig(t, "https://app.example.com")
+	assert.True(t, cfg.AllowCredentials)
+}
@@ -0,0 +1,44 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Tipos de fuente de datos soportados para la ingesta
+const (
+	SourceTypeHTTP = "http"
+	SourceTypeFile = "file"
+)
+
+// SourceConfig contiene la configuración de la fuente de datos de stocks
+type SourceConfig struct {
+	Type     string
+	APIURL   string
+	APIKey   string
+	FilePath string
+	PageSize int
+}
+
+// LoadSourceConfig lee la configuración de la fuente de datos desde las variables de entorno.
+// Si STOCK_SOURCE no está definida se usa la API HTTP.
+func LoadSourceConfig() *SourceConfig {
+	sourceType := strings.ToLower(strings.TrimSpace(os.Getenv("STOCK_SOURCE")))
+	if sourceType == "" {
+		sourceType = SourceTypeHTTP
+	}
+
+	pageSize, err := strconv.Atoi(os.Getenv("STOCK_SOURCE_PAGE_SIZE"))
+	if err != nil || pageSize <= 0 {
+		pageSize = 100
+	}
+
+	return &SourceConfig{
+		Type:     sourceType,
+		APIURL:   os.Getenv("API_URL"),
+		APIKey:   os.Getenv("API_KEY"),
+		FilePath: os.Getenv("STOCK_SOURCE_PATH"),
+		PageSize: pageSize,
+	}
+}
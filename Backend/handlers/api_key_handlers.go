@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"Backend/config"
+	"Backend/middleware"
+	"Backend/models"
+	"Backend/repositories"
+	"Backend/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// APIKeyHandler define los manejadores para administrar las API keys de los
+// clientes que no son personas.
+type APIKeyHandler struct {
+	db *gorm.DB
+}
+
+// NewAPIKeyHandler crea una nueva instancia de APIKeyHandler.
+// Retorna error si la base de datos es nil.
+func NewAPIKeyHandler(db *gorm.DB) (*APIKeyHandler, error) {
+	if db == nil {
+		return nil, errors.New("la base de datos no puede ser nil")
+	}
+	return &APIKeyHandler{db: db}, nil
+}
+
+// createAPIKeyRequest es el cuerpo de POST /admin/api-keys
+type createAPIKeyRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	RateLimit string     `json:"rate_limit"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// List obtiene las API keys vigentes; con include_revoked=true incluye las
+// revocadas
+func (h *APIKeyHandler) List(c *gin.Context) {
+	keys, err := repositories.GetAPIKeys(h.db, c.Query("include_revoked") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "error al obtener las API keys",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": keys,
+	})
+}
+
+// Create genera una API key. La clave en claro solo se retorna en esta
+// respuesta, en data.key. Solo la puede crear un usuario, con scopes de su
+// propio rol.
+func (h *APIKeyHandler) Create(c *gin.Context) {
+	claims, ok := middleware.GetClaims(c)
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "las API keys solo las puede crear un usuario",
+		})
+		return
+	}
+
+	var req createAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "el cuerpo debe ser {\"name\": texto, \"scopes\": [texto], \"rate_limit\": \"60/1m\", \"expires_at\": RFC3339}",
+		})
+		return
+	}
+
+	var createdBy *int64
+	if id, err := strconv.ParseInt(claims.Subject, 10, 64); err == nil {
+		createdBy = &id
+	}
+	keyRequest := services.NewAPIKeyRequest{
+		Name:        req.Name,
+		Scopes:      req.Scopes,
+		RateLimit:   req.RateLimit,
+		ExpiresAt:   req.ExpiresAt,
+		CreatedBy:   createdBy,
+		CreatorRole: claims.Role,
+	}
+	now := time.Now()
+	if err := services.ValidateAPIKeyRequest(keyRequest, now); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":  err.Error(),
+			"scopes": services.APIKeyScopes(claims.Role),
+		})
+		return
+	}
+
+	key, plain, err := services.CreateAPIKey(h.db, keyRequest, now)
+	if err != nil {
+		config.LogError(err, "APIKeyHandler.Create")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "error al crear la API key",
+		})
+		return
+	}
+
+	config.LogInfo("API key creada: "+key.Name+" ("+key.Prefix+")", "APIKeyHandler")
+	c.JSON(http.StatusCreated, gin.H{
+		"data": createdAPIKeyResponse{APIKey: *key, Key: plain},
+	})
+}
+
+// createdAPIKeyResponse es una API key recién creada junto con la clave en claro
+type createdAPIKeyResponse struct {
+	models.APIKey
+	Key string `json:"key"`
+}
+
+// Revoke revoca una API key
+func (h *APIKeyHandler) Revoke(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "id de API key inválido",
+		})
+		return
+	}
+
+	if err := repositories.RevokeAPIKey(h.db, id, time.Now()); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "API key no encontrada o ya revocada",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "error al revocar la API key",
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
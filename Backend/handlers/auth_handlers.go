@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"Backend/config"
+	"Backend/middleware"
+	"Backend/repositories"
+	"Backend/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// AuthHandler define los manejadores para iniciar sesión y renovar tokens.
+type AuthHandler struct {
+	db             *gorm.DB
+	securityConfig *config.SecurityConfig
+}
+
+// NewAuthHandler crea una nueva instancia de AuthHandler.
+// Retorna error si la base de datos o la configuración de seguridad son nil.
+func NewAuthHandler(db *gorm.DB, securityConfig *config.SecurityConfig) (*AuthHandler, error) {
+	if db == nil {
+		return nil, errors.New("la base de datos no puede ser nil")
+	}
+	if securityConfig == nil {
+		return nil, errors.New("la configuración de seguridad no puede ser nil")
+	}
+	return &AuthHandler{db: db, securityConfig: securityConfig}, nil
+}
+
+// loginRequest es el cuerpo de POST /auth/login
+type loginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// refreshRequest es el cuerpo de POST /auth/refresh y POST /auth/logout
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// Login verifica usuario y contraseña y emite un access token y un refresh token
+func (h *AuthHandler) Login(c *gin.Context) {
+	var req loginRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Username == "" || req.Password == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "el cuerpo debe ser {\"username\": texto, \"password\": texto}",
+		})
+		return
+	}
+
+	user, err := services.Authenticate(h.db, req.Username, req.Password)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidCredentials) {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		config.LogError(err, "AuthHandler.Login")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "error al iniciar sesión",
+		})
+		return
+	}
+
+	tokens, err := services.IssueTokens(h.db, user, h.securityConfig, time.Now())
+	if err != nil {
+		config.LogError(err, "AuthHandler.Login")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "error al iniciar sesión",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": tokens,
+	})
+}
+
+// Refresh canjea un refresh token por un nuevo par de tokens. El refresh token
+// usado deja de ser válido.
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req refreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.RefreshToken == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "el cuerpo debe ser {\"refresh_token\": texto}",
+		})
+		return
+	}
+
+	tokens, err := services.RefreshTokens(h.db, req.RefreshToken, h.securityConfig, time.Now())
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidToken) {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "refresh token inválido o vencido",
+			})
+			return
+		}
+		config.LogError(err, "AuthHandler.Refresh")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "error al renovar el token",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": tokens,
+	})
+}
+
+// Logout revoca un refresh token. El access token sigue siendo válido hasta
+// que vence.
+func (h *AuthHandler) Logout(c *gin.Context) {
+	var req refreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.RefreshToken == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "el cuerpo debe ser {\"refresh_token\": texto}",
+		})
+		return
+	}
+
+	if err := services.RevokeRefreshToken(h.db, req.RefreshToken, time.Now()); err != nil {
+		config.LogError(err, "AuthHandler.Logout")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "error al cerrar sesión",
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Me retorna el usuario del token y los permisos de su rol. Requiere
+// AuthMiddleware.
+func (h *AuthHandler) Me(c *gin.Context) {
+	claims, ok := middleware.GetClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "se requiere autenticación",
+		})
+		return
+	}
+
+	id, err := strconv.ParseInt(claims.Subject, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "token inválido o vencido",
+		})
+		return
+	}
+	user, err := repositories.GetUser(h.db, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "usuario no encontrado",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "error al obtener el usuario",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": user,
+		"metadata": gin.H{
+			"permissions": services.RolePermissions(user.Role),
+		},
+	})
+}
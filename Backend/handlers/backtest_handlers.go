@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"Backend/repositories"
+	"Backend/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// BacktestHandler define el manejador para evaluar estrategias de puntaje
+// sobre el historial de eventos.
+type BacktestHandler struct {
+	db *gorm.DB
+}
+
+// NewBacktestHandler crea una nueva instancia de BacktestHandler.
+// Retorna error si la base de datos es nil.
+func NewBacktestHandler(db *gorm.DB) (*BacktestHandler, error) {
+	if db == nil {
+		return nil, errors.New("la base de datos no puede ser nil")
+	}
+	return &BacktestHandler{db: db}, nil
+}
+
+// Run reproduce el historial día a día con una estrategia y retorna cómo
+// coincidieron sus recomendaciones con las notas siguientes de los brokerages.
+// Acepta strategy, from y to (YYYY-MM-DD), horizon (por defecto 30d) y top.
+// Como se ejecuta durante la petición, el rango se limita a
+// services.MaxBacktestAPIDays días.
+func (h *BacktestHandler) Run(c *gin.Context) {
+	strategy, _, ok := parseStrategyParams(c)
+	if !ok {
+		return
+	}
+
+	horizon := 30 * 24 * time.Hour
+	if value := c.Query("horizon"); value != "" {
+		parsed, err := parseWindow(value)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "horizon debe ser una ventana como 30d o 72h",
+			})
+			return
+		}
+		horizon = parsed
+	}
+
+	from, err := parseDateParam("from", c.Query("from"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	to, err := parseDateParam("to", c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	topN := 0
+	if value := c.Query("top"); value != "" {
+		topN, err = strconv.Atoi(value)
+		if err != nil || topN <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "top debe ser un número mayor que 0",
+			})
+			return
+		}
+	}
+
+	events, err := repositories.GetRecentStocks(h.db, time.Time{})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "error al obtener el historial de eventos",
+		})
+		return
+	}
+	scorer, err := services.LoadBrokerScorer(h.db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "error al obtener los pesos de los brokerages",
+		})
+		return
+	}
+
+	from, to = services.DefaultBacktestRange(events, horizon, from, to)
+	if days := int(to.Sub(from).Hours()/24) + 1; days > services.MaxBacktestAPIDays {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("el backtest de la API no puede superar %d días; para rangos más largos usa cmd/backtest", services.MaxBacktestAPIDays),
+		})
+		return
+	}
+
+	report, err := services.RunBacktest(events, services.BacktestOptions{
+		Strategy: strategy,
+		Brokers:  scorer,
+		From:     from,
+		To:       to,
+		Horizon:  horizon,
+		TopN:     topN,
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": report,
+	})
+}
@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"Backend/config"
+	"Backend/models"
+	"Backend/repositories"
+	"Backend/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// BrokerWeightHandler define los manejadores para administrar los pesos de
+// reputación de los brokerages.
+type BrokerWeightHandler struct {
+	db *gorm.DB
+}
+
+// NewBrokerWeightHandler crea una nueva instancia de BrokerWeightHandler.
+// Retorna error si la base de datos es nil.
+func NewBrokerWeightHandler(db *gorm.DB) (*BrokerWeightHandler, error) {
+	if db == nil {
+		return nil, errors.New("la base de datos no puede ser nil")
+	}
+	return &BrokerWeightHandler{db: db}, nil
+}
+
+// brokerWeightRequest es el cuerpo de PUT /admin/broker-weights/:brokerage
+type brokerWeightRequest struct {
+	Weight *float64 `json:"weight"`
+}
+
+// List obtiene los pesos de todos los brokerages
+func (h *BrokerWeightHandler) List(c *gin.Context) {
+	weights, err := repositories.GetBrokerWeights(h.db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "error al obtener los pesos de los brokerages",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": weights,
+		"metadata": gin.H{
+			"total_records": len(weights),
+		},
+	})
+}
+
+// Upsert crea o actualiza el peso de un brokerage
+func (h *BrokerWeightHandler) Upsert(c *gin.Context) {
+	brokerage := strings.TrimSpace(c.Param("brokerage"))
+	if brokerage == "" || len(brokerage) > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "brokerage inválido",
+		})
+		return
+	}
+
+	var req brokerWeightRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Weight == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "el cuerpo debe ser {\"weight\": número}",
+		})
+		return
+	}
+	if math.IsNaN(*req.Weight) || math.IsInf(*req.Weight, 0) || *req.Weight < -10 || *req.Weight > 10 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "weight debe ser un número entre -10 y 10",
+		})
+		return
+	}
+
+	weight := models.BrokerWeight{
+		Brokerage: brokerage,
+		Weight:    *req.Weight,
+		Source:    models.BrokerWeightSourceManual,
+		UpdatedAt: time.Now(),
+	}
+	if err := repositories.SaveBrokerWeights(h.db, []models.BrokerWeight{weight}); err != nil {
+		config.LogError(err, "BrokerWeightHandler.Upsert")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "error al guardar el peso del brokerage",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": weight,
+	})
+}
+
+// Delete elimina el peso de un brokerage, que pasa a puntuar 0
+func (h *BrokerWeightHandler) Delete(c *gin.Context) {
+	brokerage := strings.TrimSpace(c.Param("brokerage"))
+	if err := repositories.DeleteBrokerWeight(h.db, brokerage); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "brokerage no encontrado",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "error al eliminar el peso del brokerage",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Peso del brokerage eliminado",
+	})
+}
+
+// Compute calcula los pesos a partir del acierto histórico de cada brokerage.
+// Acepta horizon (por defecto 30d), lookback (por defecto todo el historial),
+// min_samples (por defecto 5) y max_weight (por defecto 2). Solo guarda los
+// pesos calculados si apply=true; en otro caso retorna una vista previa. Al
+// guardar se conservan los pesos manuales salvo con force=true y se eliminan
+// los sembrados que no se recalcularon, que están en otra escala.
+func (h *BrokerWeightHandler) Compute(c *gin.Context) {
+	opts := services.HitRateOptions{
+		Horizon:    30 * 24 * time.Hour,
+		MinSamples: 5,
+		MaxWeight:  2,
+	}
+
+	if value := c.Query("horizon"); value != "" {
+		horizon, err := parseWindow(value)
+		if err != nil || horizon <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "horizon debe ser una ventana como 30d o 72h",
+			})
+			return
+		}
+		opts.Horizon = horizon
+	}
+
+	var since time.Time
+	if value := c.Query("lookback"); value != "" {
+		lookback, err := parseWindow(value)
+		if err != nil || lookback <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "lookback debe ser una ventana como 365d",
+			})
+			return
+		}
+		since = time.Now().Add(-lookback)
+	}
+
+	if value := c.Query("min_samples"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "min_samples debe ser un número mayor que 0",
+			})
+			return
+		}
+		opts.MinSamples = parsed
+	}
+
+	maxWeight, err := parseFloatParam(c, "max_weight")
+	if err != nil || (maxWeight != nil && (*maxWeight <= 0 || *maxWeight > 10)) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "max_weight debe ser un número entre 0 y 10",
+		})
+		return
+	}
+	if maxWeight != nil {
+		opts.MaxWeight = *maxWeight
+	}
+
+	events, err := repositories.GetRecentStocks(h.db, since)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "error al obtener el historial de eventos",
+		})
+		return
+	}
+
+	rates := services.ComputeBrokerHitRates(events, services.GetScoringConfig(), opts)
+
+	apply := c.Query("apply") == "true"
+	force := c.Query("force") == "true"
+	keptManual := []string{}
+	var removedSeed int64
+	if apply {
+		now := time.Now()
+		weights := make([]models.BrokerWeight, 0, len(rates))
+		for _, rate := range rates {
+			hitRate := rate.HitRate
+			weights = append(weights, models.BrokerWeight{
+				Brokerage: rate.Brokerage,
+				Weight:    rate.Weight,
+				Source:    models.BrokerWeightSourceComputed,
+				HitRate:   &hitRate,
+				Samples:   rate.Samples,
+				UpdatedAt: now,
+			})
+		}
+		keptManual, removedSeed, err = repositories.ApplyComputedBrokerWeights(h.db, weights, force)
+		if err != nil {
+			config.LogError(err, "BrokerWeightHandler.Compute")
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "error al guardar los pesos calculados",
+			})
+			return
+		}
+		config.LogInfo("Pesos de brokerages recalculados desde el historial", "BrokerWeightHandler")
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": rates,
+		"metadata": gin.H{
+			"applied":       apply,
+			"kept_manual":   keptManual,
+			"removed_seed":  removedSeed,
+			"events":        len(events),
+			"horizon_hours": opts.Horizon.Hours(),
+			"min_samples":   opts.MinSamples,
+			"max_weight":    opts.MaxWeight,
+		},
+	})
+}
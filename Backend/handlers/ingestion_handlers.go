@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"Backend/repositories"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// IngestionHandler define los manejadores para consultar los jobs de ingesta.
+type IngestionHandler struct {
+	db *gorm.DB
+}
+
+// NewIngestionHandler crea una nueva instancia de IngestionHandler.
+// Retorna error si la base de datos es nil.
+func NewIngestionHandler(db *gorm.DB) (*IngestionHandler, error) {
+	if db == nil {
+		return nil, errors.New("la base de datos no puede ser nil")
+	}
+	return &IngestionHandler{db: db}, nil
+}
+
+// GetState obtiene el progreso de la ingesta de cada fuente
+func (h *IngestionHandler) GetState(c *gin.Context) {
+	states, err := repositories.GetIngestionStates(h.db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "error al obtener el estado de la ingesta",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": states,
+	})
+}
+
+// ListJobs obtiene los jobs de ingesta más recientes.
+// Acepta el parámetro limit (por defecto 20, máximo 100).
+func (h *IngestionHandler) ListJobs(c *gin.Context) {
+	limit := 20
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 || parsed > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "limit debe ser un número entre 1 y 100",
+			})
+			return
+		}
+		limit = parsed
+	}
+
+	jobs, err := repositories.GetIngestionJobs(h.db, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "error al obtener jobs de ingesta",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": jobs,
+		"metadata": gin.H{
+			"total_records": len(jobs),
+		},
+	})
+}
+
+// GetJob obtiene un job de ingesta por su ID
+func (h *IngestionHandler) GetJob(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "id de job inválido",
+		})
+		return
+	}
+
+	job, err := repositories.GetIngestionJob(h.db, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "job de ingesta no encontrado",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "error al obtener el job de ingesta",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": job,
+	})
+}
+
+// GetRejectedRows obtiene los registros rechazados por un job de ingesta.
+// Acepta el parámetro limit (por defecto 100, máximo 1000).
+func (h *IngestionHandler) GetRejectedRows(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "id de job inválido",
+		})
+		return
+	}
+
+	limit := 100
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 || parsed > 1000 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "limit debe ser un número entre 1 y 1000",
+			})
+			return
+		}
+		limit = parsed
+	}
+
+	rejected, err := repositories.GetQuarantinedStocks(h.db, id, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "error al obtener los registros rechazados",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": rejected,
+		"metadata": gin.H{
+			"total_records": len(rejected),
+		},
+	})
+}
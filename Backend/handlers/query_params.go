@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parsePagination lee los parámetros page y page_size de la petición
+func parsePagination(c *gin.Context, defaultSize, maxSize int) (page, pageSize int, err error) {
+	page, pageSize = 1, defaultSize
+
+	if value := c.Query("page"); value != "" {
+		page, err = strconv.Atoi(value)
+		if err != nil || page <= 0 {
+			return 0, 0, fmt.Errorf("page debe ser un número mayor que 0")
+		}
+	}
+	if value := c.Query("page_size"); value != "" {
+		pageSize, err = strconv.Atoi(value)
+		if err != nil || pageSize <= 0 || pageSize > maxSize {
+			return 0, 0, fmt.Errorf("page_size debe ser un número entre 1 y %d", maxSize)
+		}
+	}
+	return page, pageSize, nil
+}
+
+// parseTimeParam interpreta una fecha YYYY-MM-DD o RFC3339; retorna la fecha
+// cero si value está vacío. Si endOfDay es true una fecha sin hora se convierte
+// en el inicio del día siguiente, para usarla como límite exclusivo.
+func parseTimeParam(name, value string, endOfDay bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if parsed, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return parsed.UTC(), nil
+	}
+	parsed, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s debe tener el formato YYYY-MM-DD o RFC3339", name)
+	}
+	if endOfDay {
+		parsed = parsed.AddDate(0, 0, 1)
+	}
+	return parsed, nil
+}
+
+// totalPages calcula el número de páginas para un total de registros
+func totalPages(total int64, pageSize int) int64 {
+	if pageSize <= 0 {
+		return 0
+	}
+	return (total + int64(pageSize) - 1) / int64(pageSize)
+}
+
+// parseDateParam interpreta una fecha YYYY-MM-DD; retorna la fecha cero si
+// value está vacío
+func parseDateParam(name, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	parsed, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s debe tener el formato YYYY-MM-DD", name)
+	}
+	return parsed, nil
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"Backend/config"
+	"Backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ScoringHandler define los manejadores para consultar y recargar la
+// configuración del motor de puntajes.
+type ScoringHandler struct{}
+
+// NewScoringHandler crea una nueva instancia de ScoringHandler.
+func NewScoringHandler() *ScoringHandler {
+	return &ScoringHandler{}
+}
+
+// GetConfig retorna la configuración vigente del motor de puntajes
+func (h *ScoringHandler) GetConfig(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"data": services.GetScoringConfig(),
+	})
+}
+
+// Reload vuelve a cargar la configuración del motor de puntajes desde el
+// archivo. Si el archivo es inválido se mantiene la configuración actual.
+func (h *ScoringHandler) Reload(c *gin.Context) {
+	cfg, err := services.ReloadScoringConfig()
+	if err != nil {
+		config.LogError(err, "ScoringHandler.Reload")
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	config.LogInfo("Configuración de puntajes recargada", "ScoringHandler")
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Configuración de puntajes recargada",
+		"data":    cfg,
+	})
+}
+
+// parseStrategyParams lee los parámetros strategy (por defecto la estrategia
+// por defecto) y region (conjunto de etiquetas) de la petición. Si alguno es
+// inválido responde 400 y retorna false.
+func parseStrategyParams(c *gin.Context) (*services.ScoringStrategy, []services.RecommendationBand, bool) {
+	cfg := services.GetScoringConfig()
+
+	strategyName := c.Query("strategy")
+	strategy, exists := cfg.Strategy(strategyName)
+	if !exists {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":      fmt.Sprintf("estrategia desconocida %q", strategyName),
+			"strategies": cfg.StrategyNames(),
+		})
+		return nil, nil, false
+	}
+
+	bands, err := cfg.LocalizedBands(strategy, c.Query("region"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   err.Error(),
+			"regions": cfg.LabelSetNames(),
+		})
+		return nil, nil, false
+	}
+	return strategy, bands, true
+}
+
+// strategyResponse describe una estrategia de puntaje en la API
+type strategyResponse struct {
+	Name        string                        `json:"name"`
+	Description string                        `json:"description"`
+	Default     bool                          `json:"default"`
+	Components  map[string]float64            `json:"components"`
+	Bands       []services.RecommendationBand `json:"bands"`
+}
+
+// ListStrategies retorna las estrategias de puntaje disponibles
+func (h *ScoringHandler) ListStrategies(c *gin.Context) {
+	cfg := services.GetScoringConfig()
+	strategies := make([]strategyResponse, 0, len(cfg.Strategies))
+	for _, name := range cfg.StrategyNames() {
+		strategy, _ := cfg.Strategy(name)
+		components := make(map[string]float64, len(strategy.Components))
+		for _, wc := range strategy.Components {
+			components[wc.Component.Name()] = wc.Weight
+		}
+		strategies = append(strategies, strategyResponse{
+			Name:        strategy.Name,
+			Description: strategy.Description,
+			Default:     name == cfg.DefaultStrategy,
+			Components:  components,
+			Bands:       strategy.Bands,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": strategies,
+	})
+}
+
+// GetBands retorna los tramos de recomendación de una estrategia con las
+// etiquetas de la región indicada, para que el frontend dibuje la leyenda
+func (h *ScoringHandler) GetBands(c *gin.Context) {
+	strategy, bands, ok := parseStrategyParams(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": bands,
+		"metadata": gin.H{
+			"strategy": strategy.Name,
+			"region":   c.Query("region"),
+			"regions":  strategy.Config().LabelSetNames(),
+		},
+	})
+}
@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"Backend/config"
+	"Backend/models"
+	"Backend/repositories"
+	"Backend/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// SnapshotHandler define los manejadores para consultar y comparar los
+// snapshots de recomendaciones guardados tras cada ingesta.
+type SnapshotHandler struct {
+	db *gorm.DB
+}
+
+// NewSnapshotHandler crea una nueva instancia de SnapshotHandler.
+// Retorna error si la base de datos es nil.
+func NewSnapshotHandler(db *gorm.DB) (*SnapshotHandler, error) {
+	if db == nil {
+		return nil, errors.New("la base de datos no puede ser nil")
+	}
+	return &SnapshotHandler{db: db}, nil
+}
+
+// List retorna los snapshots más recientes de una estrategia, sin sus items.
+// Acepta strategy y limit (por defecto 30, máximo 365).
+func (h *SnapshotHandler) List(c *gin.Context) {
+	strategy, _, ok := parseStrategyParams(c)
+	if !ok {
+		return
+	}
+
+	limit := 30
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > 365 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "limit debe ser un entero entre 1 y 365",
+			})
+			return
+		}
+		limit = parsed
+	}
+
+	snapshots, err := repositories.GetRecommendationSnapshots(h.db, strategy.Name, limit)
+	if err != nil {
+		config.LogError(err, "SnapshotHandler.List")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "error al obtener los snapshots",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": snapshots,
+		"metadata": gin.H{
+			"strategy": strategy.Name,
+		},
+	})
+}
+
+// Get retorna el último snapshot de una estrategia tomado hasta el final de
+// la fecha indicada (YYYY-MM-DD o latest). Acepta strategy y region.
+func (h *SnapshotHandler) Get(c *gin.Context) {
+	strategy, bands, ok := parseStrategyParams(c)
+	if !ok {
+		return
+	}
+
+	before, err := snapshotDateParam("date", c.Param("date"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	snapshot, err := repositories.GetRecommendationSnapshotBefore(h.db, strategy.Name, before)
+	if err != nil {
+		h.snapshotError(c, err)
+		return
+	}
+	services.LocalizeSnapshot(snapshot, bands)
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": snapshot,
+		"metadata": gin.H{
+			"strategy": strategy.Name,
+			"bands":    bands,
+		},
+	})
+}
+
+// Diff compara dos snapshots de una estrategia: tickers que entran, que salen
+// y que cambian de tramo. to (YYYY-MM-DD, por defecto el último snapshot) y
+// from (por defecto el último snapshot del día anterior al de to) eligen el
+// último snapshot tomado hasta el final de cada fecha.
+func (h *SnapshotHandler) Diff(c *gin.Context) {
+	strategy, bands, ok := parseStrategyParams(c)
+	if !ok {
+		return
+	}
+
+	toBefore, err := snapshotDateParam("to", c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	to, err := repositories.GetRecommendationSnapshotBefore(h.db, strategy.Name, toBefore)
+	if err != nil {
+		h.snapshotError(c, err)
+		return
+	}
+
+	fromBefore, err := snapshotDateParam("from", c.Query("from"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if c.Query("from") == "" {
+		// Por defecto, lo que cambió desde el día anterior al snapshot de to
+		taken := to.TakenAt.UTC()
+		fromBefore = time.Date(taken.Year(), taken.Month(), taken.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	if fromBefore.After(toBefore) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "from debe ser anterior o igual a to",
+		})
+		return
+	}
+	from, err := repositories.GetRecommendationSnapshotBefore(h.db, strategy.Name, fromBefore)
+	if err != nil {
+		h.snapshotError(c, err)
+		return
+	}
+
+	services.LocalizeSnapshot(from, bands)
+	services.LocalizeSnapshot(to, bands)
+	diff := services.DiffRecommendationSnapshots(from, to, bands)
+	// Los items completos ya se listan en entered, exited y changed
+	diff.From = snapshotHeader(from)
+	diff.To = snapshotHeader(to)
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": diff,
+		"metadata": gin.H{
+			"strategy": strategy.Name,
+			"bands":    bands,
+		},
+	})
+}
+
+// Take guarda un snapshot de cada estrategia con los datos actuales
+func (h *SnapshotHandler) Take(c *gin.Context) {
+	snapshots, err := services.TakeRecommendationSnapshots(h.db, nil)
+	if err != nil {
+		config.LogError(err, "SnapshotHandler.Take")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "error al guardar los snapshots",
+		})
+		return
+	}
+
+	headers := make([]*models.RecommendationSnapshot, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		headers = append(headers, snapshotHeader(snapshot))
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"data": headers,
+	})
+}
+
+// snapshotError responde 404 si no hay snapshot o 500 ante otros errores
+func (h *SnapshotHandler) snapshotError(c *gin.Context, err error) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "no se encontró un snapshot para la fecha",
+		})
+		return
+	}
+	config.LogError(err, "SnapshotHandler")
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error": "error al obtener el snapshot",
+	})
+}
+
+// snapshotDateParam interpreta una fecha YYYY-MM-DD y retorna el inicio del
+// día siguiente, para buscar el último snapshot tomado hasta esa fecha. Un
+// valor vacío o latest no limita la fecha.
+func snapshotDateParam(name, value string) (time.Time, error) {
+	if value == "" || value == "latest" {
+		return time.Now().Add(time.Minute), nil
+	}
+	date, err := parseDateParam(name, value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return date.AddDate(0, 0, 1), nil
+}
+
+// snapshotHeader retorna una copia del snapshot sin sus items
+func snapshotHeader(snapshot *models.RecommendationSnapshot) *models.RecommendationSnapshot {
+	header := *snapshot
+	header.Items = nil
+	return &header
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"Backend/repositories"
+	"Backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxFilterValues limita los valores de un filtro de lista
+const maxFilterValues = 50
+
+// parseStockFilters construye los filtros de GET /stocks a partir de los
+// parámetros de la petición. Los filtros de lista aceptan valores separados
+// por comas o el parámetro repetido, y se niegan con el prefijo not_
+// (p.ej. not_brokerage). Retorna también, por cada filtro, si se aplicó.
+func parseStockFilters(c *gin.Context) (repositories.StockFilters, map[string]bool, error) {
+	var f repositories.StockFilters
+	applied := make(map[string]bool)
+	var err error
+
+	lists := []struct {
+		param    string
+		target   *[]string
+		sanitize func(string) string
+		maxLen   int
+	}{
+		{"ticker", &f.Tickers, services.SanitizeTicker, 10},
+		{"not_ticker", &f.ExcludeTickers, services.SanitizeTicker, 10},
+		{"brokerage", &f.Brokerages, services.SanitizeInput, 100},
+		{"not_brokerage", &f.ExcludeBrokerages, services.SanitizeInput, 100},
+		{"action", &f.Actions, services.SanitizeInput, 100},
+		{"not_action", &f.ExcludeActions, services.SanitizeInput, 100},
+		{"rating_from", &f.RatingsFrom, services.SanitizeInput, 50},
+		{"not_rating_from", &f.ExcludeRatingFrom, services.SanitizeInput, 50},
+		{"rating_to", &f.RatingsTo, services.SanitizeInput, 50},
+		{"not_rating_to", &f.ExcludeRatingTo, services.SanitizeInput, 50},
+	}
+	for _, list := range lists {
+		if *list.target, err = parseListParam(c, list.param, list.sanitize, list.maxLen); err != nil {
+			return f, nil, err
+		}
+		applied[list.param] = len(*list.target) > 0
+	}
+
+	f.Company = services.SanitizeInput(c.Query("company"))
+	if len(f.Company) > 100 {
+		return f, nil, fmt.Errorf("company no puede superar 100 caracteres")
+	}
+	applied["company"] = f.Company != ""
+
+	ranges := []struct {
+		name     string
+		min, max **float64
+	}{
+		{"target_from", &f.TargetFromMin, &f.TargetFromMax},
+		{"target_to", &f.TargetToMin, &f.TargetToMax},
+		{"target_change_pct", &f.TargetChangePctMin, &f.TargetChangePctMax},
+	}
+	for _, r := range ranges {
+		if *r.min, err = parseFloatParam(c, r.name+"_min"); err != nil {
+			return f, nil, err
+		}
+		if *r.max, err = parseFloatParam(c, r.name+"_max"); err != nil {
+			return f, nil, err
+		}
+		if *r.min != nil && *r.max != nil && **r.min > **r.max {
+			return f, nil, fmt.Errorf("%s_min no puede ser mayor que %s_max", r.name, r.name)
+		}
+		applied[r.name] = *r.min != nil || *r.max != nil
+	}
+
+	if f.TimeFrom, err = parseTimeParam("time_from", c.Query("time_from"), false); err != nil {
+		return f, nil, err
+	}
+	if f.TimeTo, err = parseTimeParam("time_to", c.Query("time_to"), true); err != nil {
+		return f, nil, err
+	}
+	if since := c.Query("since"); since != "" {
+		if !f.TimeFrom.IsZero() {
+			return f, nil, fmt.Errorf("since y time_from no pueden usarse a la vez")
+		}
+		window, err := parseWindow(since)
+		if err != nil {
+			return f, nil, err
+		}
+		f.TimeFrom = time.Now().UTC().Add(-window)
+	}
+	if !f.TimeFrom.IsZero() && !f.TimeTo.IsZero() && !f.TimeFrom.Before(f.TimeTo) {
+		return f, nil, fmt.Errorf("time_from debe ser anterior a time_to")
+	}
+	applied["time"] = !f.TimeFrom.IsZero() || !f.TimeTo.IsZero()
+
+	return f, applied, nil
+}
+
+// parseListParam lee un filtro de lista, sanitizando y eliminando duplicados
+func parseListParam(c *gin.Context, name string, sanitize func(string) string, maxLen int) ([]string, error) {
+	var values []string
+	seen := make(map[string]bool)
+	for _, raw := range c.QueryArray(name) {
+		for _, value := range strings.Split(raw, ",") {
+			value = sanitize(value)
+			if value == "" || seen[value] {
+				continue
+			}
+			if len(value) > maxLen {
+				return nil, fmt.Errorf("los valores de %s no pueden superar %d caracteres", name, maxLen)
+			}
+			seen[value] = true
+			values = append(values, value)
+		}
+	}
+	if len(values) > maxFilterValues {
+		return nil, fmt.Errorf("%s admite como máximo %d valores", name, maxFilterValues)
+	}
+	return values, nil
+}
+
+// parseFloatParam lee un parámetro numérico opcional
+func parseFloatParam(c *gin.Context, name string) (*float64, error) {
+	raw := c.Query(name)
+	if raw == "" {
+		return nil, nil
+	}
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+		return nil, fmt.Errorf("%s debe ser un número", name)
+	}
+	return &value, nil
+}
+
+// parseWindow interpreta una ventana de tiempo como "7d", "12h" o "30m"
+func parseWindow(value string) (time.Duration, error) {
+	if days, found := strings.CutSuffix(value, "d"); found {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("since debe ser una duración como 7d o 24h")
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	window, err := time.ParseDuration(value)
+	if err != nil || window <= 0 {
+		return 0, fmt.Errorf("since debe ser una duración como 7d o 24h")
+	}
+	return window, nil
+}
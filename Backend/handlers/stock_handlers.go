@@ -3,6 +3,9 @@ package handlers
 import (
 	"errors"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"Backend/config"
 	"Backend/models"
@@ -15,20 +18,27 @@ import (
 
 // StockHandler define los manejadores para las rutas relacionadas con las acciones.
 type StockHandler struct {
-	db *gorm.DB
+	db          *gorm.DB
+	coordinator *services.IngestionCoordinator
 }
 
 // NewStockHandler crea una nueva instancia de StockHandler.
-// Retorna error si la base de datos es nil.
-func NewStockHandler(db *gorm.DB) (*StockHandler, error) {
+// Retorna error si la base de datos o el coordinador de ingesta son nil.
+func NewStockHandler(db *gorm.DB, coordinator *services.IngestionCoordinator) (*StockHandler, error) {
 	if db == nil {
 		return nil, errors.New("la base de datos no puede ser nil")
 	}
-	return &StockHandler{db: db}, nil
+	if coordinator == nil {
+		return nil, errors.New("el coordinador de ingesta no puede ser nil")
+	}
+	return &StockHandler{db: db, coordinator: coordinator}, nil
 }
 
-// GetStocks obtiene las acciones filtradas por ticker, company y brokerage.
-// Si no se proporcionan filtros, muestra todos los datos.
+// GetStocks obtiene las acciones filtradas según los parámetros descritos en
+// parseStockFilters. Si no se proporcionan filtros, muestra todos los datos. Admite paginación
+// (page, page_size), orden (sort=campo o sort=-campo para descendente) y
+// selección de campos (fields=ticker,company). Sin page_size se retorna la
+// primera página de 50 registros; page_size=all retorna todos.
 func (h *StockHandler) GetStocks(c *gin.Context) {
 	if h.db == nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -37,36 +47,69 @@ func (h *StockHandler) GetStocks(c *gin.Context) {
 		return
 	}
 
-	// Validación y sanitización de parámetros
-	ticker := services.SanitizeInput(c.Query("ticker"))
-	company := services.SanitizeInput(c.Query("company"))
-	brokerage := services.SanitizeInput(c.Query("brokerage"))
-
-	// Limitar la longitud de los parámetros para prevenir ataques
-	if len(ticker) > 10 || len(company) > 100 || len(brokerage) > 100 {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "parámetros de búsqueda demasiado largos",
-		})
+	// Validación y sanitización de los filtros
+	filters, applied, err := parseStockFilters(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	var stocks []models.Stock
-	var err error
+	query := repositories.StockQuery{Filters: filters}
 
-	// Si no hay filtros, obtener todos los stocks
-	if ticker == "" && company == "" && brokerage == "" {
-		stocks, err = repositories.GetAllStocks(h.db)
+	// Paginación; page_size=all la desactiva de forma explícita
+	if c.Query("page_size") == "all" {
+		if c.Query("page") != "" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "page no se puede combinar con page_size=all",
+			})
+			return
+		}
 	} else {
-		stocks, err = repositories.GetStocks(h.db, ticker, company, brokerage)
+		page, pageSize, err := parsePagination(c, 50, 500)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		query.Page, query.PageSize = page, pageSize
 	}
 
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{
-				"error": "no se encontraron acciones",
+	// Orden
+	if sort := strings.TrimSpace(c.Query("sort")); sort != "" {
+		query.SortDesc = strings.HasPrefix(sort, "-")
+		query.SortBy = strings.TrimPrefix(sort, "-")
+		if order := c.Query("order"); order != "" {
+			if order != "asc" && order != "desc" {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": "order debe ser asc o desc",
+				})
+				return
+			}
+			query.SortDesc = order == "desc"
+		}
+		if !models.IsStockField(query.SortBy) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "sort debe ser uno de: " + strings.Join(models.StockFields, ", "),
 			})
 			return
 		}
+	}
+
+	// Selección de campos
+	if fields := c.Query("fields"); fields != "" {
+		for _, field := range strings.Split(fields, ",") {
+			field = strings.TrimSpace(field)
+			if !models.IsStockField(field) {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": "fields solo admite: " + strings.Join(models.StockFields, ", "),
+				})
+				return
+			}
+			query.Fields = append(query.Fields, field)
+		}
+	}
+
+	result, err := repositories.GetStocks(h.db, query)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "error al obtener acciones",
 		})
@@ -74,39 +117,45 @@ func (h *StockHandler) GetStocks(c *gin.Context) {
 	}
 
 	// Verificar si se encontraron stocks
-	if len(stocks) == 0 {
+	if result.Total == 0 {
 		c.JSON(http.StatusNotFound, gin.H{
 			"error": "no se encontraron acciones",
 		})
 		return
 	}
 
-	// Obtener la fecha más reciente
-	var latestTime string
-	if len(stocks) > 0 {
-		latestTime = stocks[0].Time
-		for _, stock := range stocks {
-			if stock.Time > latestTime {
-				latestTime = stock.Time
-			}
+	var data interface{} = result.Stocks
+	if len(query.Fields) > 0 {
+		selected := make([]map[string]interface{}, 0, len(result.Stocks))
+		for i := range result.Stocks {
+			selected = append(selected, result.Stocks[i].SelectFields(query.Fields))
 		}
+		data = selected
 	}
 
-	// Preparar la respuesta
-	response := gin.H{
-		"data": stocks,
-		"metadata": gin.H{
-			"total_records": len(stocks),
-			"last_update":   latestTime,
-			"filters_applied": gin.H{
-				"ticker":    ticker != "",
-				"company":   company != "",
-				"brokerage": brokerage != "",
-			},
-		},
+	metadata := gin.H{
+		"total_records":    result.Total,
+		"returned_records": len(result.Stocks),
+		"last_update":      result.LastUpdate,
+		"filters_applied":  applied,
+	}
+	if query.PageSize > 0 {
+		metadata["page"] = query.Page
+		metadata["page_size"] = query.PageSize
+		metadata["total_pages"] = totalPages(result.Total, query.PageSize)
+	}
+	if query.SortBy != "" {
+		metadata["sort"] = gin.H{
+			"field": query.SortBy,
+			"desc":  query.SortDesc,
+		}
 	}
 
-	c.JSON(http.StatusOK, response)
+	// Preparar la respuesta
+	c.JSON(http.StatusOK, gin.H{
+		"data":     data,
+		"metadata": metadata,
+	})
 }
 
 // GetBestStocks obtiene las mejores recomendaciones de acciones.
@@ -119,7 +168,12 @@ func (h *StockHandler) GetBestStocks(c *gin.Context) {
 		return
 	}
 
-	stocks, err := repositories.GetAllStocks(h.db)
+	strategy, bands, ok := parseStrategyParams(c)
+	if !ok {
+		return
+	}
+
+	recommendations, err := services.RecommendStocks(h.db, strategy, time.Now())
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{
@@ -134,28 +188,28 @@ func (h *StockHandler) GetBestStocks(c *gin.Context) {
 	}
 
 	// Verificar si se encontraron stocks
-	if len(stocks) == 0 {
+	if len(recommendations) == 0 {
 		c.JSON(http.StatusNotFound, gin.H{
 			"error": "no se encontraron acciones",
 		})
 		return
 	}
 
-	// Configurar el BrokerScorer con valores constantes
-	scorer := services.NewDefaultBrokerScorer(map[string]float64{
-		"The Goldman Sachs Group": 2.0,
-		"JPMorgan Chase":          1.5,
-		"Bank of America":         1.0,
-	})
-
-	recommendations := services.CalculateStockRecommendations(stocks, scorer)
+	services.LocalizeRecommendations(recommendations, bands)
 
 	c.JSON(http.StatusOK, gin.H{
 		"data": recommendations,
+		"metadata": gin.H{
+			"strategy": strategy.Name,
+			"bands":    bands,
+			"decay":    strategy.Config().Decay,
+		},
 	})
 }
 
-// UpdateStocks actualiza los datos de stocks desde la API
+// UpdateStocks actualiza los datos de stocks desde la API.
+// Acepta los parámetros opcionales mode (auto, backfill o incremental) y
+// page_budget (máximo de páginas, 0 = sin límite).
 func (h *StockHandler) UpdateStocks(c *gin.Context) {
 	if h.db == nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -164,16 +218,222 @@ func (h *StockHandler) UpdateStocks(c *gin.Context) {
 		return
 	}
 
-	// Ejecutar la actualización en una goroutine para no bloquear la respuesta
-	go func() {
-		if err := repositories.FetchAndStoreStockData(); err != nil {
-			config.LogError(err, "Error actualizando datos de stocks")
-		} else {
-			config.LogInfo("✅ Datos de stocks actualizados exitosamente", "UpdateStocks")
+	// Modo y límite de páginas opcionales para esta ejecución
+	var opts repositories.IngestionOptions
+	if mode := c.Query("mode"); mode != "" {
+		if !repositories.ValidIngestionMode(mode) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "mode debe ser auto, backfill o incremental",
+			})
+			return
+		}
+		opts.Mode = mode
+	}
+	if value := c.Query("page_budget"); value != "" {
+		budget, err := strconv.Atoi(value)
+		if err != nil || budget < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "page_budget debe ser un número mayor o igual a 0",
+			})
+			return
 		}
-	}()
+		opts.PageBudget = &budget
+	}
+
+	// El coordinador ejecuta la actualización en segundo plano y evita
+	// ingestas simultáneas
+	job, started, err := h.coordinator.Start(models.JobTriggerManual, opts)
+	if err != nil {
+		config.LogError(err, "UpdateStocks")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "error al iniciar la actualización",
+		})
+		return
+	}
+
+	if !started {
+		response := gin.H{
+			"message":         "Ya hay una actualización en curso",
+			"already_running": true,
+		}
+		if job != nil {
+			response["job_id"] = job.ID
+			response["data"] = job
+		}
+		c.JSON(http.StatusAccepted, response)
+		return
+	}
 
 	c.JSON(http.StatusAccepted, gin.H{
-		"message": "Actualización de datos iniciada",
+		"message":         "Actualización de datos iniciada",
+		"already_running": false,
+		"job_id":          job.ID,
+		"data":            job,
 	})
 }
+
+// GetStockHistory obtiene la secuencia cronológica de eventos de un ticker.
+// Acepta los parámetros from y to (YYYY-MM-DD o RFC3339), order (asc o desc),
+// page y page_size.
+func (h *StockHandler) GetStockHistory(c *gin.Context) {
+	ticker := services.SanitizeTicker(c.Param("ticker"))
+	if ticker == "" || len(ticker) > 10 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ticker inválido",
+		})
+		return
+	}
+
+	page, pageSize, err := parsePagination(c, 50, 500)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	from, err := parseTimeParam("from", c.Query("from"), false)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	to, err := parseTimeParam("to", c.Query("to"), true)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "from debe ser anterior a to",
+		})
+		return
+	}
+
+	order := c.DefaultQuery("order", "asc")
+	if order != "asc" && order != "desc" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "order debe ser asc o desc",
+		})
+		return
+	}
+
+	stocks, total, err := repositories.GetStockHistory(h.db, repositories.StockHistoryQuery{
+		Ticker:   ticker,
+		From:     from,
+		To:       to,
+		Page:     page,
+		PageSize: pageSize,
+		Desc:     order == "desc",
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "error al obtener la historia del ticker",
+		})
+		return
+	}
+
+	if total == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "no se encontraron eventos para el ticker",
+		})
+		return
+	}
+
+	history := make([]models.StockHistoryEntry, 0, len(stocks))
+	for _, stock := range stocks {
+		history = append(history, models.NewStockHistoryEntry(stock))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": history,
+		"metadata": gin.H{
+			"ticker":        ticker,
+			"total_records": total,
+			"page":          page,
+			"page_size":     pageSize,
+			"total_pages":   totalPages(total, pageSize),
+		},
+	})
+}
+
+// ExplainStock explica el puntaje del evento más reciente de un ticker: el
+// aporte de cada componente de la estrategia y la razón de cada uno
+func (h *StockHandler) ExplainStock(c *gin.Context) {
+	ticker := services.SanitizeTicker(c.Param("ticker"))
+	if ticker == "" || len(ticker) > 10 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ticker inválido",
+		})
+		return
+	}
+
+	strategy, bands, ok := parseStrategyParams(c)
+	if !ok {
+		return
+	}
+
+	recommendation, err := services.RecommendTicker(h.db, strategy, ticker, time.Now())
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "no se encontraron eventos para el ticker",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "error al obtener el ticker",
+		})
+		return
+	}
+
+	recommendations := []models.StockRecommendation{*recommendation}
+	services.LocalizeRecommendations(recommendations, bands)
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": recommendations[0],
+		"metadata": gin.H{
+			"strategy": strategy.Name,
+			"bands":    bands,
+			"decay":    strategy.Config().Decay,
+		},
+	})
+}
+
+// GetStockConsensus resume la última nota de cada brokerage sobre un ticker:
+// subidas y bajadas de calificación, precio objetivo medio y mediano y su
+// dispersión
+func (h *StockHandler) GetStockConsensus(c *gin.Context) {
+	ticker := services.SanitizeTicker(c.Param("ticker"))
+	if ticker == "" || len(ticker) > 10 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ticker inválido",
+		})
+		return
+	}
+
+	opinions, err := repositories.GetLatestStocksPerBrokerage(h.db, ticker)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "error al obtener el consenso de brokerages",
+		})
+		return
+	}
+	if len(opinions) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "no se encontraron eventos para el ticker",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": consensusResponse{
+			StockConsensus: services.BuildConsensus(ticker, opinions, services.GetScoringConfig()),
+			Opinions:       opinions,
+		},
+	})
+}
+
+// consensusResponse es el consenso de un ticker junto con la última nota de
+// cada brokerage usada para calcularlo
+type consensusResponse struct {
+	models.StockConsensus
+	Opinions []models.Stock `json:"opinions"`
+}
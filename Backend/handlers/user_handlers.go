@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"Backend/config"
+	"Backend/middleware"
+	"Backend/repositories"
+	"Backend/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// UserHandler define los manejadores de administración de usuarios.
+type UserHandler struct {
+	db *gorm.DB
+}
+
+// NewUserHandler crea una nueva instancia de UserHandler.
+// Retorna error si la base de datos es nil.
+func NewUserHandler(db *gorm.DB) (*UserHandler, error) {
+	if db == nil {
+		return nil, errors.New("la base de datos no puede ser nil")
+	}
+	return &UserHandler{db: db}, nil
+}
+
+// createUserRequest es el cuerpo de POST /admin/users
+type createUserRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Role     string `json:"role"`
+}
+
+// updateUserRequest es el cuerpo de PATCH /admin/users/:id
+type updateUserRequest struct {
+	Role     *string `json:"role"`
+	Active   *bool   `json:"active"`
+	Password *string `json:"password"`
+}
+
+// List obtiene todos los usuarios
+func (h *UserHandler) List(c *gin.Context) {
+	users, err := repositories.GetUsers(h.db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "error al obtener los usuarios",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": users,
+		"metadata": gin.H{
+			"roles": services.Roles,
+		},
+	})
+}
+
+// Create crea un usuario. El rol por defecto es viewer.
+func (h *UserHandler) Create(c *gin.Context) {
+	var req createUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "el cuerpo debe ser {\"username\": texto, \"password\": texto, \"role\": texto}",
+		})
+		return
+	}
+
+	username := strings.TrimSpace(req.Username)
+	if username == "" || len(username) > 64 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "username debe tener entre 1 y 64 caracteres",
+		})
+		return
+	}
+	if req.Role == "" {
+		req.Role = services.Roles[0]
+	}
+	if !validateUserFields(c, &req.Role, &req.Password) {
+		return
+	}
+
+	user, err := services.CreateUserAccount(h.db, username, req.Password, req.Role)
+	if err != nil {
+		if errors.Is(err, services.ErrUsernameTaken) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		config.LogError(err, "UserHandler.Create")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "error al crear el usuario",
+		})
+		return
+	}
+
+	config.LogInfo("Usuario creado: "+user.Username+" ("+user.Role+")", "UserHandler")
+	c.JSON(http.StatusCreated, gin.H{
+		"data": user,
+	})
+}
+
+// Update cambia el rol, el estado o la contraseña de un usuario
+func (h *UserHandler) Update(c *gin.Context) {
+	id, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req updateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil || (req.Role == nil && req.Active == nil && req.Password == nil) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "el cuerpo debe incluir role, active o password",
+		})
+		return
+	}
+	if !validateUserFields(c, req.Role, req.Password) {
+		return
+	}
+
+	user, err := services.UpdateUserAccount(h.db, id, services.UserUpdate{
+		Role:     req.Role,
+		Active:   req.Active,
+		Password: req.Password,
+	})
+	if err != nil {
+		h.userError(c, err, "UserHandler.Update")
+		return
+	}
+
+	config.LogInfo("Usuario actualizado: "+user.Username, "UserHandler")
+	c.JSON(http.StatusOK, gin.H{
+		"data": user,
+	})
+}
+
+// Delete elimina un usuario. Un administrador no puede eliminarse a sí mismo.
+func (h *UserHandler) Delete(c *gin.Context) {
+	id, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	if claims, exists := middleware.GetClaims(c); exists && claims.Subject == strconv.FormatInt(id, 10) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "no se puede eliminar el usuario con el que se inició sesión",
+		})
+		return
+	}
+
+	if err := services.DeleteUserAccount(h.db, id); err != nil {
+		h.userError(c, err, "UserHandler.Delete")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// userError responde según el error de una operación sobre un usuario
+func (h *UserHandler) userError(c *gin.Context, err error, context string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "usuario no encontrado",
+		})
+	case errors.Is(err, services.ErrLastAdmin):
+		c.JSON(http.StatusConflict, gin.H{
+			"error": err.Error(),
+		})
+	default:
+		config.LogError(err, context)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "error al modificar el usuario",
+		})
+	}
+}
+
+// userIDParam lee el parámetro id de la ruta. Si es inválido responde 400 y
+// retorna false.
+func userIDParam(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "id de usuario inválido",
+		})
+		return 0, false
+	}
+	return id, true
+}
+
+// validateUserFields verifica el rol y la contraseña si se indicaron. Si alguno
+// es inválido responde 400 y retorna false.
+func validateUserFields(c *gin.Context, role, password *string) bool {
+	if role != nil && !services.IsValidRole(*role) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "rol desconocido",
+			"roles": services.Roles,
+		})
+		return false
+	}
+	if password != nil {
+		if err := services.ValidatePassword(*password); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return false
+		}
+	}
+	return true
+}
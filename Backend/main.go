@@ -1,12 +1,23 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"Backend/config"
 	"Backend/handlers"
 	"Backend/middleware"
+	"Backend/models"
 	"Backend/repositories"
+	"Backend/services"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -14,7 +25,13 @@ import (
 )
 
 func main() {
-	// Cargar variables de entorno
+	// Cargar variables de entorno. Con APP_ENV definido se carga primero
+	// .env.<APP_ENV>, cuyos valores tienen prioridad sobre los de .env
+	if env := os.Getenv("APP_ENV"); env != "" {
+		if err := godotenv.Load(".env." + env); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			log.Fatalf("Error loading .env.%s file: %v", env, err)
+		}
+	}
 	if err := godotenv.Load(); err != nil {
 		log.Fatalf("Error loading .env file: %v", err)
 	}
@@ -37,43 +54,234 @@ func main() {
 	// Configurar el repositorio de stocks
 	repositories.SetDB(db)
 
-	// Actualizar datos de stocks al inicio
-	go func() {
-		if err := repositories.FetchAndStoreStockData(); err != nil {
+	// Sembrar los pesos de brokerages si aún no hay ninguno
+	if err := repositories.SeedBrokerWeights(db, services.DefaultBrokerWeights()); err != nil {
+		log.Fatalf("Error seeding broker weights: %v", err)
+	}
+
+	// Crear el primer usuario a partir de ADMIN_USERNAME y ADMIN_PASSWORD
+	created, err := services.BootstrapAdmin(db, securityConfig.AdminUsername, securityConfig.AdminPassword)
+	if errors.Is(err, services.ErrBootstrapPasswordMismatch) {
+		config.LogError(err, "main")
+	} else if err != nil {
+		log.Fatalf("Error creating admin user: %v", err)
+	}
+	if created {
+		config.LogInfo("Usuario administrador creado: "+securityConfig.AdminUsername, "main")
+	}
+
+	// Configurar la fuente de datos de stocks
+	stockSource, err := repositories.NewStockSource(config.LoadSourceConfig())
+	if err != nil {
+		log.Fatalf("Error configuring stock source: %v", err)
+	}
+	repositories.SetStockSource(stockSource)
+	config.LogInfo("Fuente de datos: "+stockSource.Describe(), "main")
+
+	// Cargar la configuración del motor de puntajes
+	if _, err := services.ReloadScoringConfig(); err != nil {
+		log.Fatalf("Error loading scoring config: %v", err)
+	}
+
+	// Contexto cancelado al recibir una señal de apagado
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Coordinar las ingestas para que no se ejecuten en paralelo
+	ingestionConfig := config.LoadIngestionConfig()
+	coordinator := services.NewIngestionCoordinator(ctx, db, ingestionConfig.AdvisoryLock, ingestionConfig.StaleJobAfter)
+
+	// Guardar un snapshot de las recomendaciones tras cada ingesta y eliminar
+	// los que superan la retención
+	coordinator.AfterIngestion(func(ctx context.Context, job *models.IngestionJob) error {
+		tx := db.WithContext(ctx)
+		if _, err := services.TakeRecommendationSnapshots(tx, &job.ID); err != nil {
+			return err
+		}
+		pruned, err := services.PruneRecommendationSnapshots(tx, config.LoadIngestionConfig().SnapshotRetentionDays, time.Now())
+		if err != nil {
+			return err
+		}
+		if pruned > 0 {
+			config.LogInfo(fmt.Sprintf("%d snapshots de recomendaciones eliminados por retención", pruned), "main")
+		}
+		return nil
+	})
+
+	// Programar la ingesta periódica de stocks
+	schedulerConfig := config.LoadSchedulerConfig()
+	var scheduler *services.Scheduler
+	if schedulerConfig.Enabled {
+		schedule, err := services.ParseSchedule(schedulerConfig.Schedule)
+		if err != nil {
+			log.Fatalf("Error parsing ingestion schedule: %v", err)
+		}
+		scheduler = services.NewScheduler("IngestionScheduler", schedule, schedulerConfig.Jitter, func(ctx context.Context) error {
+			_, err := coordinator.Run(ctx, models.JobTriggerScheduler, repositories.IngestionOptions{})
+			if errors.Is(err, services.ErrIngestionInProgress) {
+				config.LogInfo("Ingesta en curso, se omite la ejecución programada", "IngestionScheduler")
+				return nil
+			}
+			return err
+		})
+		scheduler.Start(ctx, schedulerConfig.RunOnStart)
+		config.LogInfo("Ingesta programada: "+schedulerConfig.Schedule, "main")
+	} else if schedulerConfig.RunOnStart {
+		// Actualizar datos de stocks al inicio
+		if _, _, err := coordinator.Start(models.JobTriggerStartup, repositories.IngestionOptions{}); err != nil {
 			log.Printf("Error fetching initial stock data: %v", err)
-		} else {
-			log.Println("✅ Datos de stocks actualizados exitosamente")
 		}
-	}()
+	}
 
 	// Configurar el enrutador
 	r := gin.Default()
 
+	// Solo se usa X-Forwarded-For de los proxies configurados, para que un
+	// cliente no pueda cambiar su IP y evadir el rate limiting
+	rateLimitConfig := config.LoadRateLimitConfig()
+	if err := r.SetTrustedProxies(rateLimitConfig.TrustedProxies); err != nil {
+		log.Fatalf("Error configuring trusted proxies: %v", err)
+	}
+	rateLimiter := middleware.NewRateLimiter(middleware.NewMemoryRateLimitStore(), rateLimitConfig)
+
 	// Aplicar middleware de seguridad
-	r.Use(middleware.SecurityMiddleware())
+	r.Use(middleware.SecurityMiddleware(securityConfig, rateLimiter, rateLimitConfig.TrustedProxies))
 
-	// Configurar CORS de forma segura
+	// Configurar CORS con los mismos orígenes que valida SecurityMiddleware
 	corsConfig := cors.DefaultConfig()
-	corsConfig.AllowOrigins = securityConfig.AllowedOrigins
-	corsConfig.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
-	corsConfig.AllowHeaders = []string{"Origin", "Content-Type", "Authorization"}
-	corsConfig.AllowCredentials = true
+	corsConfig.AllowOriginFunc = securityConfig.IsOriginAllowed
+	corsConfig.AllowMethods = securityConfig.AllowedMethods
+	corsConfig.AllowHeaders = []string{"Origin", "Content-Type", "Authorization", middleware.APIKeyHeader}
+	corsConfig.AllowCredentials = securityConfig.AllowCredentials
 	r.Use(cors.New(corsConfig))
 
 	// Configurar los manejadores
-	stockHandler, err := handlers.NewStockHandler(db)
+	stockHandler, err := handlers.NewStockHandler(db, coordinator)
 	if err != nil {
 		log.Fatalf("Error creating stock handler: %v", err)
 	}
 
-	// Definir las rutas
-	r.GET("/stocks", stockHandler.GetStocks)
-	r.GET("/stocks/recommendations", stockHandler.GetBestStocks)
-	r.POST("/stocks/update", stockHandler.UpdateStocks)
+	ingestionHandler, err := handlers.NewIngestionHandler(db)
+	if err != nil {
+		log.Fatalf("Error creating ingestion handler: %v", err)
+	}
+
+	brokerWeightHandler, err := handlers.NewBrokerWeightHandler(db)
+	if err != nil {
+		log.Fatalf("Error creating broker weight handler: %v", err)
+	}
+
+	backtestHandler, err := handlers.NewBacktestHandler(db)
+	if err != nil {
+		log.Fatalf("Error creating backtest handler: %v", err)
+	}
+
+	snapshotHandler, err := handlers.NewSnapshotHandler(db)
+	if err != nil {
+		log.Fatalf("Error creating snapshot handler: %v", err)
+	}
+
+	authHandler, err := handlers.NewAuthHandler(db, securityConfig)
+	if err != nil {
+		log.Fatalf("Error creating auth handler: %v", err)
+	}
+
+	apiKeyHandler, err := handlers.NewAPIKeyHandler(db)
+	if err != nil {
+		log.Fatalf("Error creating API key handler: %v", err)
+	}
+
+	userHandler, err := handlers.NewUserHandler(db)
+	if err != nil {
+		log.Fatalf("Error creating user handler: %v", err)
+	}
+
+	scoringHandler := handlers.NewScoringHandler()
+
+	// Las rutas aceptan un access token o una API key en X-API-Key
+	verifyAPIKey := func(key string) (*models.APIKey, error) {
+		return services.VerifyAPIKey(db, key, time.Now())
+	}
+
+	// Definir las rutas públicas; si incluyen credenciales se limitan por
+	// usuario o API key en lugar de por IP y deben tener permiso de consulta
+	public := r.Group("/",
+		middleware.OptionalAuthMiddleware(securityConfig, verifyAPIKey),
+		rateLimiter.Group(config.RateLimitGroupPublic),
+		middleware.RequirePermissionIfAuthenticated(services.PermissionViewStocks),
+	)
+	public.GET("/stocks", stockHandler.GetStocks)
+	public.GET("/stocks/recommendations", stockHandler.GetBestStocks)
+	public.GET("/stocks/recommendations/strategies", scoringHandler.ListStrategies)
+	public.GET("/stocks/recommendations/bands", scoringHandler.GetBands)
+	public.GET("/stocks/recommendations/snapshots", snapshotHandler.List)
+	public.GET("/stocks/recommendations/snapshots/diff", snapshotHandler.Diff)
+	public.GET("/stocks/recommendations/snapshots/:date", snapshotHandler.Get)
+	public.GET("/stocks/:ticker/history", stockHandler.GetStockHistory)
+	public.GET("/stocks/:ticker/explain", stockHandler.ExplainStock)
+	public.GET("/stocks/:ticker/consensus", stockHandler.GetStockConsensus)
+	public.GET("/scoring/config", scoringHandler.GetConfig)
+
+	auth := r.Group("/auth", rateLimiter.Group(config.RateLimitGroupAuth))
+	auth.POST("/login", authHandler.Login)
+	auth.POST("/refresh", authHandler.Refresh)
+	auth.POST("/logout", authHandler.Logout)
+
+	// Rutas que requieren un access token; cada una exige un permiso del rol
+	authorized := r.Group("/", middleware.AuthMiddleware(securityConfig, verifyAPIKey), rateLimiter.Group(config.RateLimitGroupAuthenticated))
+	authorized.GET("/auth/me", authHandler.Me)
+	authorized.POST("/stocks/update", middleware.RequirePermission(services.PermissionRunIngestion), stockHandler.UpdateStocks)
+	authorized.POST("/scoring/reload", middleware.RequirePermission(services.PermissionReloadScoring), scoringHandler.Reload)
+	authorized.GET("/scoring/backtest", middleware.RequirePermission(services.PermissionRunBacktest), backtestHandler.Run)
+
+	ingestion := authorized.Group("/ingestion", middleware.RequirePermission(services.PermissionViewIngestion))
+	ingestion.GET("/state", ingestionHandler.GetState)
+	ingestion.GET("/jobs", ingestionHandler.ListJobs)
+	ingestion.GET("/jobs/:id", ingestionHandler.GetJob)
+	ingestion.GET("/jobs/:id/rejected", ingestionHandler.GetRejectedRows)
+
+	admin := authorized.Group("/admin", rateLimiter.Group(config.RateLimitGroupAdmin))
+	admin.GET("/broker-weights", middleware.RequirePermission(services.PermissionViewBrokerWeights), brokerWeightHandler.List)
+	admin.PUT("/broker-weights/:brokerage", middleware.RequirePermission(services.PermissionEditBrokerWeights), brokerWeightHandler.Upsert)
+	admin.DELETE("/broker-weights/:brokerage", middleware.RequirePermission(services.PermissionEditBrokerWeights), brokerWeightHandler.Delete)
+	admin.POST("/broker-weights/compute", middleware.RequirePermission(services.PermissionEditBrokerWeights), brokerWeightHandler.Compute)
+	admin.POST("/recommendation-snapshots", middleware.RequirePermission(services.PermissionTakeSnapshots), snapshotHandler.Take)
+
+	apiKeys := admin.Group("/api-keys", middleware.RequirePermission(services.PermissionManageAPIKeys))
+	apiKeys.GET("", apiKeyHandler.List)
+	apiKeys.POST("", apiKeyHandler.Create)
+	apiKeys.DELETE("/:id", apiKeyHandler.Revoke)
+
+	users := admin.Group("/users", middleware.RequirePermission(services.PermissionManageUsers))
+	users.GET("", userHandler.List)
+	users.POST("", userHandler.Create)
+	users.PATCH("/:id", userHandler.Update)
+	users.DELETE("/:id", userHandler.Delete)
 
 	// Iniciar el servidor
-	config.LogInfo("API running at http://localhost:9090", "main")
-	if err := r.Run(":9090"); err != nil {
-		log.Fatalf("Error starting server: %v", err)
+	srv := &http.Server{
+		Addr:    ":9090",
+		Handler: r,
+	}
+	go func() {
+		config.LogInfo("API running at http://localhost:9090", "main")
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Error starting server: %v", err)
+		}
+	}()
+
+	// Esperar la señal de apagado y cerrar de forma ordenada
+	<-ctx.Done()
+	config.LogInfo("Apagando el servidor...", "main")
+
+	if scheduler != nil {
+		scheduler.Stop()
+	}
+	coordinator.Wait()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Error shutting down server: %v", err)
 	}
 }
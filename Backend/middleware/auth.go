@@ -0,0 +1,135 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"Backend/config"
+	"Backend/models"
+	"Backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyHeader es la cabecera con la que los clientes envían su API key
+const APIKeyHeader = "X-API-Key"
+
+// Claves del contexto de gin donde se guardan las credenciales verificadas
+const (
+	claimsKey = "auth_claims"
+	apiKeyKey = "auth_api_key"
+)
+
+// APIKeyVerifier retorna la API key vigente que corresponde a una clave en
+// claro, o services.ErrInvalidAPIKey si no hay ninguna
+type APIKeyVerifier func(key string) (*models.APIKey, error)
+
+// AuthMiddleware exige un access token válido en la cabecera
+// "Authorization: Bearer <token>" o una API key en X-API-Key, y guarda las
+// credenciales en el contexto
+func AuthMiddleware(securityConfig *config.SecurityConfig, apiKeys APIKeyVerifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !hasCredentials(c) {
+			c.Header("WWW-Authenticate", `Bearer realm="api"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "se requiere autenticación",
+			})
+			return
+		}
+		if authenticate(c, securityConfig, apiKeys) {
+			c.Next()
+		}
+	}
+}
+
+// OptionalAuthMiddleware verifica las credenciales si la petición las incluye
+// y deja pasar las peticiones anónimas. Permite que las rutas públicas limiten
+// por usuario o API key en lugar de por IP.
+func OptionalAuthMiddleware(securityConfig *config.SecurityConfig, apiKeys APIKeyVerifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !hasCredentials(c) || authenticate(c, securityConfig, apiKeys) {
+			c.Next()
+		}
+	}
+}
+
+// GetClaims retorna los claims del token verificado por AuthMiddleware
+func GetClaims(c *gin.Context) (*services.TokenClaims, bool) {
+	value, exists := c.Get(claimsKey)
+	if !exists {
+		return nil, false
+	}
+	claims, ok := value.(*services.TokenClaims)
+	return claims, ok
+}
+
+// GetAPIKey retorna la API key verificada por AuthMiddleware
+func GetAPIKey(c *gin.Context) (*models.APIKey, bool) {
+	value, exists := c.Get(apiKeyKey)
+	if !exists {
+		return nil, false
+	}
+	key, ok := value.(*models.APIKey)
+	return key, ok
+}
+
+// hasCredentials indica si la petición incluye un token o una API key
+func hasCredentials(c *gin.Context) bool {
+	return c.GetHeader(APIKeyHeader) != "" || c.GetHeader("Authorization") != ""
+}
+
+// authenticate verifica la API key o el access token de la petición y los
+// guarda en el contexto. Si son inválidos responde 401 y retorna false.
+func authenticate(c *gin.Context, securityConfig *config.SecurityConfig, apiKeys APIKeyVerifier) bool {
+	if plain := c.GetHeader(APIKeyHeader); plain != "" {
+		key, err := apiKeys(plain)
+		if err != nil {
+			if !errors.Is(err, services.ErrInvalidAPIKey) {
+				config.LogError(err, "AuthMiddleware")
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+					"error": "error al verificar la API key",
+				})
+				return false
+			}
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": err.Error(),
+			})
+			return false
+		}
+		c.Set(apiKeyKey, key)
+		return true
+	}
+
+	token, ok := bearerToken(c.GetHeader("Authorization"))
+	if !ok {
+		c.Header("WWW-Authenticate", `Bearer realm="api"`)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "se requiere autenticación",
+		})
+		return false
+	}
+
+	claims, err := services.ParseToken(token, securityConfig.JWTSecret, time.Now())
+	if err != nil {
+		c.Header("WWW-Authenticate", `Bearer realm="api", error="invalid_token"`)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "token inválido o vencido",
+		})
+		return false
+	}
+
+	c.Set(claimsKey, claims)
+	return true
+}
+
+// bearerToken extrae el token de una cabecera Authorization con esquema Bearer
+func bearerToken(header string) (string, bool) {
+	scheme, token, found := strings.Cut(strings.TrimSpace(header), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
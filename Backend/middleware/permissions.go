@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"net/http"
+
+	"Backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequirePermission exige que el rol del token, o los scopes de la API key,
+// incluyan el permiso indicado. Debe usarse después de AuthMiddleware.
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var allowed bool
+		if key, ok := GetAPIKey(c); ok {
+			allowed = services.APIKeyAllows(key, permission)
+		} else if claims, ok := GetClaims(c); ok {
+			allowed = services.HasPermission(claims.Role, permission)
+		} else {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "se requiere autenticación",
+			})
+			return
+		}
+
+		if !allowed {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error":      "permisos insuficientes",
+				"permission": permission,
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequirePermissionIfAuthenticated deja pasar las peticiones anónimas y exige
+// el permiso indicado a las que incluyen credenciales. Se usa en las rutas
+// públicas después de OptionalAuthMiddleware, para que una API key sin el
+// scope correspondiente no pueda usarlas.
+func RequirePermissionIfAuthenticated(permission string) gin.HandlerFunc {
+	require := RequirePermission(permission)
+	return func(c *gin.Context) {
+		_, hasKey := GetAPIKey(c)
+		_, hasClaims := GetClaims(c)
+		if !hasKey && !hasClaims {
+			c.Next()
+			return
+		}
+		require(c)
+	}
+}
@@ -0,0 +1,210 @@
+package middleware
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"Backend/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimitResult es el resultado de consumir una petición de un bucket
+type RateLimitResult struct {
+	Allowed bool
+	// Limit es la capacidad del bucket
+	Limit int
+	// Remaining son las peticiones disponibles tras esta
+	Remaining int
+	// Reset es el tiempo hasta que el bucket vuelve a estar lleno
+	Reset time.Duration
+	// RetryAfter es el tiempo hasta la próxima petición permitida si Allowed es false
+	RetryAfter time.Duration
+}
+
+// RateLimitStore guarda los buckets de los clientes. MemoryRateLimitStore
+// sirve para una réplica; con varias réplicas se necesita una implementación
+// compartida (p.ej. Redis) para que el límite sea común a todas.
+type RateLimitStore interface {
+	// Take consume una petición del bucket key según rule
+	Take(ctx context.Context, key string, rule config.RateLimitRule, now time.Time) (RateLimitResult, error)
+}
+
+// tokenBucket es el estado de un bucket en memoria
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+	// full es el momento en que el bucket vuelve a estar lleno si no recibe
+	// más peticiones; a partir de entonces se puede descartar
+	full time.Time
+}
+
+// MemoryRateLimitStore guarda los buckets en memoria del proceso
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+// NewMemoryRateLimitStore crea un store en memoria
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{buckets: make(map[string]*tokenBucket)}
+}
+
+// Take implementa RateLimitStore con un token bucket
+func (s *MemoryRateLimitStore) Take(_ context.Context, key string, rule config.RateLimitRule, now time.Time) (RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+
+	capacity := float64(rule.Requests)
+	rate := capacity / rule.Per.Seconds()
+
+	bucket, exists := s.buckets[key]
+	if !exists {
+		bucket = &tokenBucket{tokens: capacity, updated: now}
+		s.buckets[key] = bucket
+	}
+	if elapsed := now.Sub(bucket.updated).Seconds(); elapsed > 0 {
+		bucket.tokens = math.Min(capacity, bucket.tokens+elapsed*rate)
+		bucket.updated = now
+	}
+
+	result := RateLimitResult{Limit: rule.Requests}
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = secondsToDuration((1 - bucket.tokens) / rate)
+	}
+	result.Remaining = int(bucket.tokens)
+	result.Reset = secondsToDuration((capacity - bucket.tokens) / rate)
+	bucket.full = now.Add(result.Reset)
+	return result, nil
+}
+
+// sweep descarta cada minuto los buckets que ya se llenaron de nuevo, ya que
+// equivalen a uno nuevo
+func (s *MemoryRateLimitStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+	for key, bucket := range s.buckets {
+		if !now.Before(bucket.full) {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// RateLimiter aplica los límites de RateLimitConfig con un RateLimitStore
+type RateLimiter struct {
+	store  RateLimitStore
+	config *config.RateLimitConfig
+}
+
+// NewRateLimiter crea un RateLimiter
+func NewRateLimiter(store RateLimitStore, cfg *config.RateLimitConfig) *RateLimiter {
+	return &RateLimiter{store: store, config: cfg}
+}
+
+// Group limita las peticiones de un grupo de rutas por cliente: la API key,
+// el usuario autenticado o, si no hay, la IP. Una API key con límite propio
+// usa ese límite en lugar del del grupo. Para limitar por usuario o API key
+// debe usarse después de AuthMiddleware u OptionalAuthMiddleware.
+func (l *RateLimiter) Group(name string) gin.HandlerFunc {
+	groupRule := l.config.Groups[name]
+	return func(c *gin.Context) {
+		rule := groupRule
+		if key, ok := GetAPIKey(c); ok && key.RateLimit != "" {
+			if keyRule, err := config.ParseRateLimitRule(key.RateLimit); err == nil {
+				rule = keyRule
+			}
+		}
+		if l.allow(c, name+":"+clientKey(c), rule) {
+			c.Next()
+		}
+	}
+}
+
+// allowIP aplica el límite global por IP
+func (l *RateLimiter) allowIP(c *gin.Context) bool {
+	return l.allow(c, "global:ip:"+c.ClientIP(), l.config.Global)
+}
+
+// rateLimitResultKey es la clave del contexto de gin donde se guarda el
+// resultado del bucket más restrictivo de la petición
+const rateLimitResultKey = "rate_limit_result"
+
+// allow consume una petición del bucket key. Si se superó el límite responde
+// 429 con Retry-After y retorna false. Si el store falla se deja pasar la
+// petición. Como una petición pasa por varios límites (global, grupo,
+// admin), las cabeceras RateLimit-* solo se escriben si este bucket es más
+// restrictivo que los anteriores, de modo que describen el límite que se
+// agotará primero.
+func (l *RateLimiter) allow(c *gin.Context, key string, rule config.RateLimitRule) bool {
+	if rule.Disabled() {
+		return true
+	}
+
+	result, err := l.store.Take(c.Request.Context(), key, rule, time.Now())
+	if err != nil {
+		config.LogError(err, "RateLimiter")
+		return true
+	}
+
+	if previous, exists := c.Get(rateLimitResultKey); !exists || tighter(result, previous.(RateLimitResult)) {
+		c.Set(rateLimitResultKey, result)
+		c.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("RateLimit-Reset", ceilSeconds(result.Reset))
+		c.Header("RateLimit-Policy", strconv.Itoa(rule.Requests)+";w="+ceilSeconds(rule.Per))
+	}
+	if result.Allowed {
+		return true
+	}
+
+	c.Header("Retry-After", ceilSeconds(result.RetryAfter))
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+		"error": "demasiadas peticiones",
+	})
+	return false
+}
+
+// tighter indica si el resultado a deja menos margen que b: una petición
+// rechazada, menos peticiones restantes o, a igualdad, más tiempo hasta que
+// el bucket se vuelve a llenar
+func tighter(a, b RateLimitResult) bool {
+	if a.Allowed != b.Allowed {
+		return !a.Allowed
+	}
+	if a.Remaining != b.Remaining {
+		return a.Remaining < b.Remaining
+	}
+	return a.Reset > b.Reset
+}
+
+// clientKey identifica al cliente de una petición para el rate limiting
+func clientKey(c *gin.Context) string {
+	if key, ok := GetAPIKey(c); ok {
+		return "key:" + strconv.FormatInt(key.ID, 10)
+	}
+	if claims, ok := GetClaims(c); ok {
+		return "user:" + claims.Subject
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// ceilSeconds retorna la duración en segundos enteros redondeando hacia arriba
+func ceilSeconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"Backend/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryRateLimitStoreTake(t *testing.T) {
+	store := NewMemoryRateLimitStore()
+	rule := config.RateLimitRule{Requests: 3, Per: 3 * time.Second}
+	now := time.Now()
+
+	for i := 2; i >= 0; i-- {
+		result, err := store.Take(context.Background(), "cliente", rule, now)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, 3, result.Limit)
+		assert.Equal(t, i, result.Remaining)
+	}
+
+	result, err := store.Take(context.Background(), "cliente", rule, now)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+	assert.Equal(t, time.Second, result.RetryAfter)
+	assert.Equal(t, 3*time.Second, result.Reset)
+}
+
+func TestMemoryRateLimitStoreTakeRefills(t *testing.T) {
+	store := NewMemoryRateLimitStore()
+	rule := config.RateLimitRule{Requests: 2, Per: 2 * time.Second}
+	now := time.Now()
+
+	for i := 0; i < 2; i++ {
+		_, err := store.Take(context.Background(), "cliente", rule, now)
+		require.NoError(t, err)
+	}
+
+	// Cada segundo se recupera una petición
+	result, err := store.Take(context.Background(), "cliente", rule, now.Add(time.Second))
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+
+	// Nunca se acumulan más peticiones que la capacidad
+	result, err = store.Take(context.Background(), "cliente", rule, now.Add(time.Hour))
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 1, result.Remaining)
+}
+
+func TestMemoryRateLimitStoreTakeSeparatesKeys(t *testing.T) {
+	store := NewMemoryRateLimitStore()
+	rule := config.RateLimitRule{Requests: 1, Per: time.Minute}
+	now := time.Now()
+
+	result, err := store.Take(context.Background(), "a", rule, now)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+
+	result, err = store.Take(context.Background(), "b", rule, now)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+
+	result, err = store.Take(context.Background(), "a", rule, now)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+}
+
+func TestRateLimiterWritesTightestHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	limiter := NewRateLimiter(NewMemoryRateLimitStore(), &config.RateLimitConfig{
+		Global: config.RateLimitRule{Requests: 100, Per: time.Minute},
+		Groups: map[string]config.RateLimitRule{
+			"estricto": {Requests: 2, Per: time.Minute},
+			"holgado":  {Requests: 50, Per: time.Minute},
+		},
+	})
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		if limiter.allowIP(c) {
+			c.Next()
+		}
+	})
+	r.GET("/", limiter.Group("estricto"), limiter.Group("holgado"), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "2;w=60", w.Header().Get("RateLimit-Policy"))
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "2", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+}
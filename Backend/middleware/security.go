@@ -2,60 +2,93 @@ package middleware
 
 import (
 	"net/http"
+	"net/netip"
+	"strings"
 
 	"Backend/config"
 
 	"github.com/gin-gonic/gin"
 )
 
-// SecurityMiddleware implementa medidas de seguridad básicas
-func SecurityMiddleware() gin.HandlerFunc {
+// SecurityMiddleware implementa medidas de seguridad básicas con las cabeceras
+// y los orígenes de securityConfig. limiter aplica el límite global de
+// peticiones por IP; nil lo desactiva. X-Forwarded-Proto solo se tiene en
+// cuenta en las peticiones que llegan desde trustedProxies (IPs o rangos CIDR).
+func SecurityMiddleware(securityConfig *config.SecurityConfig, limiter *RateLimiter, trustedProxies []string) gin.HandlerFunc {
+	hsts := securityConfig.HSTSHeader()
+	proxies := parseTrustedProxies(trustedProxies)
 	return func(c *gin.Context) {
 		// Prevenir clickjacking
-		c.Header("X-Frame-Options", "DENY")
+		if securityConfig.FrameOptions != "" {
+			c.Header("X-Frame-Options", securityConfig.FrameOptions)
+		}
 		// Habilitar XSS protection
 		c.Header("X-XSS-Protection", "1; mode=block")
 		// Prevenir MIME type sniffing
 		c.Header("X-Content-Type-Options", "nosniff")
 		// Configurar CSP
-		c.Header("Content-Security-Policy", "default-src 'self'")
-		// Configurar HSTS
-		c.Header("Strict-Transport-Security", "max-age=31536000; includeSubDomains")
+		if securityConfig.ContentSecurityPolicy != "" {
+			c.Header("Content-Security-Policy", securityConfig.ContentSecurityPolicy)
+		}
+		// Configurar HSTS; los navegadores la ignoran en HTTP, así que solo
+		// se envía en HTTPS
+		if hsts != "" && isHTTPS(c, proxies) {
+			c.Header("Strict-Transport-Security", hsts)
+		}
 
 		// Validar origen de la petición
-		origin := c.GetHeader("Origin")
-		if origin != "" {
-			allowedOrigins := config.GetSecurityConfig().AllowedOrigins
-			isAllowed := false
-			for _, allowed := range allowedOrigins {
-				if origin == allowed {
-					isAllowed = true
-					break
-				}
-			}
-			if !isAllowed {
-				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
-					"error": "origen no permitido",
-				})
-				return
-			}
-		}
-
-		// Rate limiting básico (implementar con Redis en producción)
-		clientIP := c.ClientIP()
-		if isRateLimited(clientIP) {
-			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
-				"error": "demasiadas peticiones",
+		if origin := c.GetHeader("Origin"); origin != "" && !securityConfig.IsOriginAllowed(origin) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": "origen no permitido",
 			})
 			return
 		}
 
+		// Rate limiting por IP
+		if limiter != nil && !limiter.allowIP(c) {
+			return
+		}
+
 		c.Next()
 	}
 }
 
-// isRateLimited implementa un rate limiting básico para pruebas locales
-func isRateLimited(ip string) bool {
+// isHTTPS indica si la petición llegó por HTTPS, directamente o a través de
+// un proxy de confianza que termina TLS. La cabecera X-Forwarded-Proto de
+// cualquier otro cliente se ignora, ya que la puede falsificar.
+func isHTTPS(c *gin.Context, proxies []netip.Prefix) bool {
+	if c.Request.TLS != nil {
+		return true
+	}
+	if !strings.EqualFold(c.GetHeader("X-Forwarded-Proto"), "https") {
+		return false
+	}
+	remote, err := netip.ParseAddr(c.RemoteIP())
+	if err != nil {
+		return false
+	}
+	remote = remote.Unmap()
+	for _, proxy := range proxies {
+		if proxy.Contains(remote) {
+			return true
+		}
+	}
+	return false
+}
 
-	return false // Deshabilitado para pruebas
+// parseTrustedProxies interpreta las IPs y rangos CIDR de los proxies de
+// confianza, descartando los inválidos (gin ya los valida al configurarlos)
+func parseTrustedProxies(values []string) []netip.Prefix {
+	var prefixes []netip.Prefix
+	for _, value := range values {
+		if prefix, err := netip.ParsePrefix(value); err == nil {
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		if addr, err := netip.ParseAddr(value); err == nil {
+			addr = addr.Unmap()
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+		}
+	}
+	return prefixes
 }
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsHTTPSTrustsForwardedProtoOnlyFromProxies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	proxies := parseTrustedProxies([]string{"10.0.0.0/8", "192.168.1.1", "inválido"})
+
+	cases := []struct {
+		remote string
+		proto  string
+		want   bool
+	}{
+		{"10.1.2.3:4000", "https", true},
+		{"192.168.1.1:4000", "HTTPS", true},
+		{"192.168.1.2:4000", "https", false},
+		{"203.0.113.5:4000", "https", false},
+		{"10.1.2.3:4000", "http", false},
+		{"10.1.2.3:4000", "", false},
+	}
+	for _, tc := range cases {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+		c.Request.RemoteAddr = tc.remote
+		if tc.proto != "" {
+			c.Request.Header.Set("X-Forwarded-Proto", tc.proto)
+		}
+		assert.Equal(t, tc.want, isHTTPS(c, proxies), "%s con X-Forwarded-Proto %q", tc.remote, tc.proto)
+	}
+}
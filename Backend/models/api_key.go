@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// APIKey es una credencial para clientes que no son personas (scripts,
+// notebooks, cron jobs). Solo se guarda el hash de la clave.
+type APIKey struct {
+	ID   int64  `gorm:"primaryKey" json:"id"`
+	Name string `gorm:"not null" json:"name"`
+	// Prefix son los primeros caracteres de la clave, para reconocerla
+	Prefix  string `gorm:"not null" json:"prefix"`
+	KeyHash string `gorm:"uniqueIndex;not null" json:"-"`
+	// Scopes son los permisos de la clave, con los mismos nombres que los de
+	// los roles
+	Scopes []string `gorm:"serializer:json;type:text" json:"scopes"`
+	// RateLimit reemplaza el límite del grupo de rutas para esta clave
+	// ("<peticiones>/<duración>"); vacío usa el del grupo
+	RateLimit  string     `json:"rate_limit"`
+	CreatedBy  *int64     `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// Orígenes del peso de un brokerage
+const (
+	// BrokerWeightSourceSeed es un peso sembrado al iniciar con la tabla vacía
+	BrokerWeightSourceSeed = "seed"
+	// BrokerWeightSourceManual es un peso asignado desde la API de administración
+	BrokerWeightSourceManual = "manual"
+	// BrokerWeightSourceComputed es un peso calculado a partir del historial
+	BrokerWeightSourceComputed = "computed"
+)
+
+// BrokerWeight es el peso de reputación de un brokerage en el puntaje
+type BrokerWeight struct {
+	Brokerage string  `gorm:"primaryKey" json:"brokerage"`
+	Weight    float64 `json:"weight"`
+	Source    string  `json:"source"`
+	// HitRate y Samples se guardan cuando el peso se calculó del historial
+	HitRate   *float64  `json:"hit_rate"`
+	Samples   int       `json:"samples"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
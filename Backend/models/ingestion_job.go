@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// Estados de un job de ingesta
+const (
+	JobStatusRunning   = "running"
+	JobStatusSucceeded = "succeeded"
+	JobStatusFailed    = "failed"
+)
+
+// Origen de la ejecución de un job de ingesta
+const (
+	JobTriggerStartup   = "startup"
+	JobTriggerScheduler = "scheduler"
+	JobTriggerManual    = "manual"
+)
+
+// IngestionJob registra una ejecución de la ingesta de stocks
+type IngestionJob struct {
+	ID         int64      `gorm:"primaryKey" json:"id"`
+	Trigger    string     `json:"trigger"`
+	Source     string     `json:"source"`
+	Mode       string     `json:"mode"`
+	PageBudget int        `json:"page_budget"`
+	Status     string     `gorm:"index" json:"status"`
+	StartedAt  time.Time  `gorm:"index" json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+	// UpdatedAt se renueva cada vez que el job guarda su progreso, así que
+	// sirve para detectar jobs que quedaron en running por una caída
+	UpdatedAt    time.Time `gorm:"index" json:"updated_at"`
+	PagesFetched int       `json:"pages_fetched"`
+	RowsUpserted int64     `json:"rows_upserted"`
+	RowsSkipped  int64     `json:"rows_skipped"`
+	RowsRejected int64     `json:"rows_rejected"`
+	// StartCursor es el cursor desde el que empezó el job y Cursor el de la
+	// siguiente página pendiente; si el job falla se puede reanudar desde él
+	StartCursor string `json:"start_cursor,omitempty"`
+	Cursor      string `json:"cursor,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// Modos de ingesta
+const (
+	// IngestionModeAuto continúa un backfill pendiente y, si no lo hay, hace una ingesta incremental
+	IngestionModeAuto = "auto"
+	// IngestionModeBackfill recorre todas las páginas de la fuente, reanudando desde el cursor guardado
+	IngestionModeBackfill = "backfill"
+	// IngestionModeIncremental recorre las páginas hasta alcanzar eventos ya vistos
+	IngestionModeIncremental = "incremental"
+)
+
+// IngestionState guarda el progreso de la ingesta de una fuente entre ejecuciones
+type IngestionState struct {
+	Source string `gorm:"primaryKey" json:"source"`
+	// Cursor es la siguiente página pendiente de un backfill interrumpido
+	Cursor string `json:"cursor"`
+	// BackfillComplete indica que el último backfill llegó a la última página
+	BackfillComplete bool `json:"backfill_complete"`
+	// BackfillMaxTime es el evento más reciente visto durante el backfill en curso
+	BackfillMaxTime string `json:"backfill_max_time"`
+	// IncrementalCursor es la siguiente página de una ingesta incremental que
+	// se detuvo (por el límite de páginas o un error) antes de alcanzar
+	// LastSeenTime; la siguiente incremental continúa desde ella
+	IncrementalCursor string `json:"incremental_cursor"`
+	// IncrementalMaxTime es el evento más reciente visto durante la ingesta
+	// incremental en curso; pasa a LastSeenTime cuando se completa
+	IncrementalMaxTime string `json:"incremental_max_time"`
+	// LastSeenTime es el evento más reciente ingerido por completo; las
+	// ingestas incrementales se detienen al alcanzarlo
+	LastSeenTime string    `json:"last_seen_time"`
+	LastJobID    int64     `json:"last_job_id"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// QuarantinedStock guarda un registro upstream rechazado durante la ingesta
+// junto con el motivo del rechazo
+type QuarantinedStock struct {
+	ID         int64     `gorm:"primaryKey" json:"id"`
+	JobID      int64     `gorm:"index" json:"job_id"`
+	Source     string    `json:"source"`
+	RawPayload string    `gorm:"type:text" json:"raw_payload"`
+	Reason     string    `json:"reason"`
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// RecommendationSnapshot guarda las recomendaciones de una estrategia en un
+// momento dado, normalmente al terminar una ingesta
+type RecommendationSnapshot struct {
+	ID       int64  `gorm:"primaryKey" json:"id"`
+	Strategy string `gorm:"index" json:"strategy"`
+	// JobID es el job de ingesta tras el que se tomó; nil si se tomó a mano
+	JobID     *int64                       `json:"job_id"`
+	TakenAt   time.Time                    `gorm:"index" json:"taken_at"`
+	ItemCount int                          `json:"item_count"`
+	Items     []RecommendationSnapshotItem `gorm:"foreignKey:SnapshotID" json:"items,omitempty"`
+}
+
+// RecommendationSnapshotItem es la recomendación de un ticker en un snapshot
+type RecommendationSnapshotItem struct {
+	ID                int64   `gorm:"primaryKey" json:"-"`
+	SnapshotID        int64   `gorm:"index" json:"-"`
+	Rank              int     `json:"rank"`
+	Ticker            string  `json:"ticker"`
+	Company           string  `json:"company"`
+	Brokerage         string  `json:"brokerage"`
+	EventTime         string  `json:"event_time"`
+	Score             float64 `json:"score"`
+	Recommendation    string  `json:"recommendation"`
+	RecommendationKey string  `json:"recommendation_key"`
+}
+
+// SnapshotChange es un ticker que cambió de tramo entre dos snapshots
+type SnapshotChange struct {
+	Ticker  string                     `json:"ticker"`
+	From    RecommendationSnapshotItem `json:"from"`
+	To      RecommendationSnapshotItem `json:"to"`
+	Upgrade bool                       `json:"upgrade"`
+}
+
+// SnapshotDiff compara dos snapshots de recomendaciones
+type SnapshotDiff struct {
+	From *RecommendationSnapshot `json:"from"`
+	To   *RecommendationSnapshot `json:"to"`
+	// Entered son los tickers que aparecen en To y no en From
+	Entered []RecommendationSnapshotItem `json:"entered"`
+	// Exited son los tickers que aparecen en From y no en To
+	Exited []RecommendationSnapshotItem `json:"exited"`
+	// Changed son los tickers presentes en ambos con distinto tramo
+	Changed []SnapshotChange `json:"changed"`
+}
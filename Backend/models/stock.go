@@ -17,6 +17,44 @@ type Stock struct {
 	Time       string  `json:"time"`
 }
 
+// StockFields son los nombres JSON de los campos de Stock, que coinciden con
+// las columnas de la base de datos
+var StockFields = []string{
+	"id", "ticker", "company", "target_from", "target_to",
+	"action", "brokerage", "rating_from", "rating_to", "time",
+}
+
+// IsStockField indica si name es un campo de Stock
+func IsStockField(name string) bool {
+	for _, field := range StockFields {
+		if field == name {
+			return true
+		}
+	}
+	return false
+}
+
+// SelectFields retorna solo los campos indicados del stock
+func (s *Stock) SelectFields(fields []string) map[string]interface{} {
+	all := map[string]interface{}{
+		"id":          s.ID,
+		"ticker":      s.Ticker,
+		"company":     s.Company,
+		"target_from": s.TargetFrom,
+		"target_to":   s.TargetTo,
+		"action":      s.Action,
+		"brokerage":   s.Brokerage,
+		"rating_from": s.RatingFrom,
+		"rating_to":   s.RatingTo,
+		"time":        s.Time,
+	}
+	selected := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		selected[field] = all[field]
+	}
+	return selected
+}
+
 // Método para convertir a DTO
 func (s *Stock) ToDTO() map[string]interface{} {
 	return map[string]interface{}{
@@ -41,4 +79,25 @@ type StockRecommendation struct {
 	Stock          Stock   `json:"stock"`
 	Score          float64 `json:"score"`
 	Recommendation string  `json:"recommendation"`
+	// RecommendationKey identifica el tramo de la recomendación sin depender
+	// de la etiqueta, que puede cambiar por región
+	RecommendationKey string           `json:"recommendation_key"`
+	Breakdown         []ScoreBreakdown `json:"breakdown"`
+	// DecayFactor es el peso por antigüedad del evento más reciente (1 = de hoy)
+	DecayFactor float64 `json:"decay_factor"`
+	// EventCount es la cantidad de eventos del ticker que se combinaron
+	EventCount int `json:"event_count"`
+	// Consensus resume la última nota de cada brokerage cuando la estrategia la usa
+	Consensus *StockConsensus `json:"consensus,omitempty"`
+}
+
+// ScoreBreakdown es el aporte de un componente al puntaje de una recomendación
+type ScoreBreakdown struct {
+	Component string `json:"component"`
+	// Value es el puntaje del componente antes de aplicar el peso
+	Value  float64 `json:"value"`
+	Weight float64 `json:"weight"`
+	// Contribution es Value * Weight, lo que suma al puntaje total
+	Contribution float64 `json:"contribution"`
+	Reason       string  `json:"reason"`
 }
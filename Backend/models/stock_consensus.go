@@ -0,0 +1,24 @@
+package models
+
+// StockConsensus resume la opinión más reciente de cada brokerage sobre un ticker
+type StockConsensus struct {
+	Ticker     string `json:"ticker"`
+	Brokerages int    `json:"brokerages"`
+	// Upgrades, Downgrades y Maintained cuentan los brokerages cuya última
+	// nota sube, baja o mantiene la calificación
+	Upgrades   int `json:"upgrades"`
+	Downgrades int `json:"downgrades"`
+	Maintained int `json:"maintained"`
+	// Los precios objetivo solo consideran los brokerages que publican uno
+	Targets      int     `json:"targets"`
+	MeanTarget   float64 `json:"mean_target"`
+	MedianTarget float64 `json:"median_target"`
+	MinTarget    float64 `json:"min_target"`
+	MaxTarget    float64 `json:"max_target"`
+	TargetStdDev float64 `json:"target_std_dev"`
+	// TargetDispersion es TargetStdDev / MeanTarget: 0 indica acuerdo total
+	TargetDispersion float64 `json:"target_dispersion"`
+	// MedianPreviousTarget es la mediana de los precios objetivo anteriores
+	MedianPreviousTarget float64 `json:"median_previous_target"`
+	LastUpdate           string  `json:"last_update"`
+}
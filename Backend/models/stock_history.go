@@ -0,0 +1,24 @@
+package models
+
+// StockHistoryEntry es un evento de la historia de un ticker junto con el
+// cambio respecto al precio objetivo y la calificación anteriores
+type StockHistoryEntry struct {
+	Stock
+	TargetChange    float64  `json:"target_change"`
+	TargetChangePct *float64 `json:"target_change_pct"`
+	RatingChanged   bool     `json:"rating_changed"`
+}
+
+// NewStockHistoryEntry construye la entrada de historia de un evento
+func NewStockHistoryEntry(stock Stock) StockHistoryEntry {
+	entry := StockHistoryEntry{
+		Stock:         stock,
+		TargetChange:  stock.TargetTo - stock.TargetFrom,
+		RatingChanged: stock.RatingFrom != stock.RatingTo,
+	}
+	if stock.TargetFrom > 0 {
+		pct := entry.TargetChange / stock.TargetFrom * 100
+		entry.TargetChangePct = &pct
+	}
+	return entry
+}
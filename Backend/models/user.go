@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// Roles de usuario, de menor a mayor acceso
+const (
+	// RoleViewer puede consultar acciones y recomendaciones
+	RoleViewer = "viewer"
+	// RoleAnalyst además puede consultar la ingesta y ejecutar backtests
+	RoleAnalyst = "analyst"
+	// RoleAdmin además puede ejecutar ingestas, editar pesos y gestionar usuarios
+	RoleAdmin = "admin"
+)
+
+// User es un usuario que puede autenticarse en la API
+type User struct {
+	ID           int64     `gorm:"primaryKey" json:"id"`
+	Username     string    `gorm:"uniqueIndex;not null" json:"username"`
+	PasswordHash string    `gorm:"not null" json:"-"`
+	Role         string    `gorm:"not null;default:viewer" json:"role"`
+	Active       bool      `gorm:"not null" json:"active"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// RefreshToken permite obtener un nuevo access token sin volver a enviar la
+// contraseña. Solo se guarda el hash del token.
+type RefreshToken struct {
+	ID        int64     `gorm:"primaryKey"`
+	UserID    int64     `gorm:"index;not null"`
+	TokenHash string    `gorm:"uniqueIndex;not null"`
+	ExpiresAt time.Time `gorm:"not null"`
+	// RevokedAt se asigna al usar el token (se rota) o al cerrar sesión
+	RevokedAt *time.Time
+	CreatedAt time.Time
+}
@@ -0,0 +1,65 @@
+package repositories
+
+import (
+	"time"
+
+	"Backend/models"
+
+	"gorm.io/gorm"
+)
+
+// CreateAPIKey guarda una API key
+func CreateAPIKey(db *gorm.DB, key *models.APIKey) error {
+	return db.Create(key).Error
+}
+
+// GetAPIKeys obtiene las API keys, las más recientes primero. Las revocadas
+// solo se incluyen si includeRevoked es true.
+func GetAPIKeys(db *gorm.DB, includeRevoked bool) ([]models.APIKey, error) {
+	query := db.Order("created_at DESC, id DESC")
+	if !includeRevoked {
+		query = query.Where("revoked_at IS NULL")
+	}
+
+	var keys []models.APIKey
+	if err := query.Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// GetAPIKeyByHash obtiene una API key por el hash de la clave
+func GetAPIKeyByHash(db *gorm.DB, keyHash string) (*models.APIKey, error) {
+	var key models.APIKey
+	if err := db.Where("key_hash = ?", keyHash).First(&key).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// RevokeAPIKey revoca una API key. Retorna gorm.ErrRecordNotFound si no
+// existía o ya estaba revocada.
+func RevokeAPIKey(db *gorm.DB, id int64, at time.Time) error {
+	result := db.Model(&models.APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", at)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// RevokeUserAPIKeys revoca las API keys vigentes creadas por un usuario
+func RevokeUserAPIKeys(db *gorm.DB, userID int64, at time.Time) error {
+	return db.Model(&models.APIKey{}).
+		Where("created_by = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", at).Error
+}
+
+// TouchAPIKey actualiza el último uso de una API key
+func TouchAPIKey(db *gorm.DB, id int64, at time.Time) error {
+	return db.Model(&models.APIKey{}).Where("id = ?", id).Update("last_used_at", at).Error
+}
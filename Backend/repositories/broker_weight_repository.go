@@ -0,0 +1,112 @@
+package repositories
+
+import (
+	"Backend/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GetBrokerWeights obtiene los pesos de todos los brokerages
+func GetBrokerWeights(db *gorm.DB) ([]models.BrokerWeight, error) {
+	var weights []models.BrokerWeight
+	if err := db.Order("weight DESC, brokerage ASC").Find(&weights).Error; err != nil {
+		return nil, err
+	}
+	return weights, nil
+}
+
+// SaveBrokerWeights crea o actualiza los pesos indicados
+func SaveBrokerWeights(db *gorm.DB, weights []models.BrokerWeight) error {
+	if len(weights) == 0 {
+		return nil
+	}
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "brokerage"}},
+		DoUpdates: clause.AssignmentColumns([]string{"weight", "source", "hit_rate", "samples", "updated_at"}),
+	}).Create(&weights).Error
+}
+
+// DeleteBrokerWeight elimina el peso de un brokerage. Retorna
+// gorm.ErrRecordNotFound si no existía.
+func DeleteBrokerWeight(db *gorm.DB, brokerage string) error {
+	result := db.Where("brokerage = ?", brokerage).Delete(&models.BrokerWeight{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// SeedBrokerWeights guarda los pesos indicados solo si la tabla está vacía,
+// para no sobrescribir los que se editaron desde la API
+func SeedBrokerWeights(db *gorm.DB, defaults map[string]float64) error {
+	var count int64
+	if err := db.Model(&models.BrokerWeight{}).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	weights := make([]models.BrokerWeight, 0, len(defaults))
+	for brokerage, weight := range defaults {
+		weights = append(weights, models.BrokerWeight{
+			Brokerage: brokerage,
+			Weight:    weight,
+			Source:    models.BrokerWeightSourceSeed,
+		})
+	}
+	return SaveBrokerWeights(db, weights)
+}
+
+// ApplyComputedBrokerWeights guarda los pesos calculados del historial en una
+// transacción. Los pesos manuales se conservan salvo que force sea true, y los
+// sembrados que no se recalcularon se eliminan para que todos los pesos queden
+// en la misma escala. Retorna los brokerages manuales conservados y la cantidad
+// de pesos sembrados eliminados.
+func ApplyComputedBrokerWeights(db *gorm.DB, weights []models.BrokerWeight, force bool) ([]string, int64, error) {
+	kept := []string{}
+	var removed int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var existing []models.BrokerWeight
+		if err := tx.Find(&existing).Error; err != nil {
+			return err
+		}
+		manual := make(map[string]bool)
+		for _, weight := range existing {
+			if weight.Source == models.BrokerWeightSourceManual {
+				manual[weight.Brokerage] = true
+			}
+		}
+
+		computed := make([]string, 0, len(weights))
+		toSave := make([]models.BrokerWeight, 0, len(weights))
+		for _, weight := range weights {
+			computed = append(computed, weight.Brokerage)
+			if manual[weight.Brokerage] && !force {
+				kept = append(kept, weight.Brokerage)
+				continue
+			}
+			toSave = append(toSave, weight)
+		}
+
+		query := tx.Where("source = ?", models.BrokerWeightSourceSeed)
+		if len(computed) > 0 {
+			query = query.Where("brokerage NOT IN ?", computed)
+		}
+		result := query.Delete(&models.BrokerWeight{})
+		if result.Error != nil {
+			return result.Error
+		}
+		removed = result.RowsAffected
+
+		return SaveBrokerWeights(tx, toSave)
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return kept, removed, nil
+}
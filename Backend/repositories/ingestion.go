@@ -0,0 +1,340 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"Backend/config"
+	"Backend/models"
+
+	"gorm.io/gorm/clause"
+)
+
+var (
+	stockSource StockSource
+	sourceMu    sync.Mutex
+)
+
+// SetStockSource asigna la fuente de datos usada por la ingesta
+func SetStockSource(source StockSource) {
+	sourceMu.Lock()
+	defer sourceMu.Unlock()
+	stockSource = source
+}
+
+// getStockSource retorna la fuente configurada, creándola desde las variables
+// de entorno si no se asignó ninguna
+func getStockSource() (StockSource, error) {
+	sourceMu.Lock()
+	defer sourceMu.Unlock()
+	if stockSource == nil {
+		source, err := NewStockSource(config.LoadSourceConfig())
+		if err != nil {
+			return nil, err
+		}
+		stockSource = source
+	}
+	return stockSource, nil
+}
+
+// IngestionOptions permite ajustar una ejecución de la ingesta
+type IngestionOptions struct {
+	// Mode es auto, backfill o incremental; vacío usa el de la configuración
+	Mode string
+	// PageBudget es el máximo de páginas (0 = sin límite); nil usa el de la configuración
+	PageBudget *int
+}
+
+// ValidIngestionMode indica si mode es un modo de ingesta válido
+func ValidIngestionMode(mode string) bool {
+	switch mode {
+	case models.IngestionModeAuto, models.IngestionModeBackfill, models.IngestionModeIncremental:
+		return true
+	}
+	return false
+}
+
+// NewIngestionJob registra un job de ingesta para la fuente configurada
+func NewIngestionJob(trigger string, opts IngestionOptions) (*models.IngestionJob, error) {
+	source, err := getStockSource()
+	if err != nil {
+		return nil, fmt.Errorf("error configuring stock source: %v", err)
+	}
+
+	cfg := config.LoadIngestionConfig()
+	job := &models.IngestionJob{
+		Trigger:    trigger,
+		Source:     source.Name(),
+		Mode:       cfg.Mode,
+		PageBudget: cfg.PageBudget,
+	}
+	if opts.Mode != "" {
+		job.Mode = opts.Mode
+	}
+	if opts.PageBudget != nil {
+		job.PageBudget = *opts.PageBudget
+	}
+	if !ValidIngestionMode(job.Mode) {
+		return nil, fmt.Errorf("invalid ingestion mode %q", job.Mode)
+	}
+
+	if err := CreateIngestionJob(db, job); err != nil {
+		return nil, fmt.Errorf("error creating ingestion job: %v", err)
+	}
+	return job, nil
+}
+
+// RunIngestionJob ejecuta la ingesta de un job ya registrado y persiste su resultado
+func RunIngestionJob(ctx context.Context, job *models.IngestionJob) error {
+	source, err := getStockSource()
+	if err == nil {
+		err = FetchAndStoreStockDataFrom(ctx, source, job)
+	}
+	if saveErr := FinishIngestionJob(db, job, err); saveErr != nil {
+		config.LogError(saveErr, "RunIngestionJob")
+	}
+	return err
+}
+
+// FetchAndStoreStockData registra un job y ejecuta la ingesta de forma síncrona.
+// La ingesta se interrumpe entre páginas si se cancela ctx.
+func FetchAndStoreStockData(ctx context.Context, trigger string, opts IngestionOptions) (*models.IngestionJob, error) {
+	job, err := NewIngestionJob(trigger, opts)
+	if err != nil {
+		return nil, err
+	}
+	return job, RunIngestionJob(ctx, job)
+}
+
+// FetchAndStoreStockDataFrom ingiere los stocks desde la fuente indicada,
+// acumulando páginas y filas procesadas en job.
+//
+// En modo backfill se recorre la fuente completa: se parte del cursor guardado
+// si un backfill anterior quedó a medias y el cursor se persiste tras cada
+// página. En modo incremental se parte de la primera página y se detiene al
+// encontrar una página sin eventos posteriores a LastSeenTime, asumiendo que la
+// fuente entrega primero los eventos más recientes; si una incremental anterior
+// se detuvo antes de llegar (límite de páginas o error), se continúa desde su
+// cursor para no releer siempre las mismas páginas. El modo auto hace backfill
+// mientras no se haya completado uno e incremental después.
+func FetchAndStoreStockDataFrom(ctx context.Context, source StockSource, job *models.IngestionJob) error {
+	state, err := GetIngestionState(db, source.Name())
+	if err != nil {
+		return fmt.Errorf("error reading ingestion state: %v", err)
+	}
+
+	mode := job.Mode
+	if mode == models.IngestionModeAuto {
+		mode = models.IngestionModeIncremental
+		if !state.BackfillComplete {
+			mode = models.IngestionModeBackfill
+		}
+	}
+
+	nextPage := ""
+	if mode == models.IngestionModeBackfill {
+		if state.BackfillComplete {
+			// Un backfill explícito tras uno completo vuelve a empezar
+			state.BackfillComplete = false
+			state.Cursor = ""
+			state.BackfillMaxTime = ""
+		}
+		nextPage = state.Cursor
+	}
+	// Las marcas de tiempo se comparan como time.Time: como texto RFC3339
+	// fallan con fracciones de segundo de distinta longitud o con zonas horarias
+	var runMaxTime time.Time
+	if mode == models.IngestionModeIncremental {
+		nextPage = state.IncrementalCursor
+		runMaxTime = parseWatermark(state.IncrementalMaxTime)
+	}
+	job.StartCursor = nextPage
+	watermark := parseWatermark(state.LastSeenTime)
+	pageDelay := config.LoadIngestionConfig().PageDelay
+
+	fmt.Printf(" Ingesta %s desde %s", mode, source.Describe())
+	if nextPage != "" {
+		fmt.Printf(" (reanudando desde el cursor %s)", nextPage)
+	}
+	fmt.Printf("\n")
+
+	reachedEnd := false
+	for {
+		// Verificar si hemos alcanzado el límite de páginas
+		if job.PageBudget > 0 && job.PagesFetched >= job.PageBudget {
+			fmt.Printf("Límite de %d páginas alcanzado\n", job.PageBudget)
+			break
+		}
+
+		fmt.Printf(" Obteniendo página %d...\n", job.PagesFetched+1)
+		stockData, err := source.FetchPage(ctx, nextPage)
+		if err != nil {
+			return fmt.Errorf("error fetching data from %s at cursor %q: %v", source.Name(), nextPage, err)
+		}
+		job.PagesFetched++
+
+		// Decodificar los registros, enviando a cuarentena los inválidos y
+		// descartando eventos repetidos dentro de la misma página
+		var stocks []models.Stock
+		var rejected []models.QuarantinedStock
+		seen := make(map[string]bool, len(stockData.Items))
+		newEvents := 0
+		for _, item := range stockData.Items {
+			stock, err := DecodeStockItem(item)
+			if err != nil {
+				rejected = append(rejected, newQuarantinedStock(job, item, err))
+				continue
+			}
+			key := stock.Ticker + "|" + stock.Time
+			if seen[key] {
+				job.RowsSkipped++
+				continue
+			}
+			seen[key] = true
+			// El decoder ya validó que la fecha es RFC3339
+			eventTime, _ := time.Parse(time.RFC3339Nano, stock.Time)
+			if eventTime.After(runMaxTime) {
+				runMaxTime = eventTime
+			}
+			if eventTime.After(watermark) {
+				newEvents++
+			}
+			stocks = append(stocks, stock)
+		}
+
+		if len(rejected) > 0 {
+			if err := QuarantineStocks(db, rejected); err != nil {
+				return fmt.Errorf("error storing rejected stocks: %v", err)
+			}
+			job.RowsRejected += int64(len(rejected))
+		}
+
+		if len(stocks) > 0 {
+			// Realizar un UPSERT usando GORM
+			result := db.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "ticker"}, {Name: "time"}},
+				DoUpdates: clause.AssignmentColumns([]string{"target_from", "target_to", "action", "brokerage", "rating_from", "rating_to"}),
+			}).Create(&stocks)
+
+			if result.Error != nil {
+				return fmt.Errorf("error inserting/updating stocks: %v", result.Error)
+			}
+
+			job.RowsUpserted += result.RowsAffected
+		}
+
+		// Registrar el cursor de la siguiente página para poder reanudar
+		nextPage = stockData.NextPage
+		job.Cursor = nextPage
+		if err := SaveIngestionJob(db, job); err != nil {
+			config.LogError(err, "FetchAndStoreStockData")
+		}
+		if mode == models.IngestionModeBackfill {
+			state.Cursor = nextPage
+			state.LastJobID = job.ID
+			if runMaxTime.After(parseWatermark(state.BackfillMaxTime)) {
+				state.BackfillMaxTime = formatWatermark(runMaxTime)
+			}
+			if err := SaveIngestionState(db, state); err != nil {
+				return fmt.Errorf("error saving ingestion state: %v", err)
+			}
+		}
+		if mode == models.IngestionModeIncremental {
+			state.IncrementalCursor = nextPage
+			state.IncrementalMaxTime = formatWatermark(runMaxTime)
+			state.LastJobID = job.ID
+			if err := SaveIngestionState(db, state); err != nil {
+				return fmt.Errorf("error saving ingestion state: %v", err)
+			}
+		}
+
+		// Verificar si hay más páginas
+		if nextPage == "" {
+			fmt.Printf("Ultima Pagina\n")
+			reachedEnd = true
+			break
+		}
+
+		// En modo incremental una página sin eventos nuevos indica que se
+		// alcanzó lo ya ingerido
+		if mode == models.IngestionModeIncremental && !watermark.IsZero() && newEvents == 0 {
+			fmt.Printf("Eventos ya ingeridos alcanzados\n")
+			reachedEnd = true
+			break
+		}
+
+		// Pequeña pausa para no sobrecargar la API
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(pageDelay):
+		}
+	}
+
+	// Actualizar la marca de agua solo cuando se cubrió todo el rango, para no
+	// saltarse eventos en la siguiente ingesta incremental
+	state.LastJobID = job.ID
+	switch {
+	case mode == models.IngestionModeBackfill && reachedEnd:
+		state.BackfillComplete = true
+		state.Cursor = ""
+		state.LastSeenTime = formatWatermark(latest(watermark, parseWatermark(state.BackfillMaxTime)))
+		state.BackfillMaxTime = ""
+	case mode == models.IngestionModeIncremental && reachedEnd:
+		state.LastSeenTime = formatWatermark(latest(watermark, runMaxTime))
+		state.IncrementalCursor = ""
+		state.IncrementalMaxTime = ""
+	case mode == models.IngestionModeIncremental:
+		config.LogInfo("Ingesta incremental detenida por el límite de páginas antes de alcanzar los eventos ya ingeridos; la siguiente continúa desde el cursor "+state.IncrementalCursor, "FetchAndStoreStockData")
+	}
+	if err := SaveIngestionState(db, state); err != nil {
+		return fmt.Errorf("error saving ingestion state: %v", err)
+	}
+
+	fmt.Printf("✅ Proceso completado:\n")
+	fmt.Printf("   - Total de stocks procesados: %d\n", job.RowsUpserted)
+	fmt.Printf("   - Total de stocks rechazados: %d\n", job.RowsRejected)
+	return nil
+}
+
+// parseWatermark interpreta una marca de tiempo guardada en el estado de
+// ingesta; una vacía o inválida equivale a no haber visto ningún evento
+func parseWatermark(text string) time.Time {
+	t, err := time.Parse(time.RFC3339Nano, text)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// formatWatermark serializa una marca de tiempo en UTC para el estado de ingesta
+func formatWatermark(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func latest(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
+
+// newQuarantinedStock construye el registro de cuarentena de un item rechazado
+func newQuarantinedStock(job *models.IngestionJob, item map[string]interface{}, reason error) models.QuarantinedStock {
+	payload, err := json.Marshal(item)
+	if err != nil {
+		payload = []byte(fmt.Sprintf("%v", item))
+	}
+	return models.QuarantinedStock{
+		JobID:      job.ID,
+		Source:     job.Source,
+		RawPayload: string(payload),
+		Reason:     reason.Error(),
+	}
+}
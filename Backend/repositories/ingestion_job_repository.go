@@ -0,0 +1,115 @@
+package repositories
+
+import (
+	"time"
+
+	"Backend/models"
+
+	"gorm.io/gorm"
+)
+
+// CreateIngestionJob registra un nuevo job de ingesta en estado running
+func CreateIngestionJob(db *gorm.DB, job *models.IngestionJob) error {
+	job.Status = models.JobStatusRunning
+	job.StartedAt = time.Now().UTC()
+	return db.Create(job).Error
+}
+
+// SaveIngestionJob persiste el progreso de un job de ingesta
+func SaveIngestionJob(db *gorm.DB, job *models.IngestionJob) error {
+	return db.Save(job).Error
+}
+
+// FinishIngestionJob marca el job como terminado con el resultado de la ejecución
+func FinishIngestionJob(db *gorm.DB, job *models.IngestionJob, runErr error) error {
+	finishedAt := time.Now().UTC()
+	job.FinishedAt = &finishedAt
+	if runErr != nil {
+		job.Status = models.JobStatusFailed
+		job.Error = runErr.Error()
+	} else {
+		job.Status = models.JobStatusSucceeded
+	}
+	return db.Save(job).Error
+}
+
+// GetIngestionJobs obtiene los jobs de ingesta más recientes
+func GetIngestionJobs(db *gorm.DB, limit int) ([]models.IngestionJob, error) {
+	var jobs []models.IngestionJob
+
+	result := db.Order("started_at DESC").
+		Limit(limit).
+		Find(&jobs)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return jobs, nil
+}
+
+// GetIngestionJob obtiene un job de ingesta por su ID.
+// Retorna gorm.ErrRecordNotFound si no existe.
+func GetIngestionJob(db *gorm.DB, id int64) (*models.IngestionJob, error) {
+	var job models.IngestionJob
+	if err := db.First(&job, id).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// GetRunningIngestionJob obtiene el job en curso más reciente.
+// Retorna gorm.ErrRecordNotFound si no hay ninguno.
+func GetRunningIngestionJob(db *gorm.DB) (*models.IngestionJob, error) {
+	var job models.IngestionJob
+	result := db.Where("status = ?", models.JobStatusRunning).
+		Order("started_at DESC").
+		First(&job)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &job, nil
+}
+
+// FailStaleIngestionJobs marca como fallidos los jobs en estado running que no
+// han guardado progreso desde staleBefore; con staleBefore en cero marca todos.
+// Marcar todos solo es seguro con el advisory lock tomado, porque entonces
+// ningún job puede estar ejecutándose en otra réplica. Retorna la cantidad de
+// jobs marcados.
+func FailStaleIngestionJobs(db *gorm.DB, staleBefore time.Time) (int64, error) {
+	finishedAt := time.Now().UTC()
+	query := db.Model(&models.IngestionJob{}).Where("status = ?", models.JobStatusRunning)
+	if !staleBefore.IsZero() {
+		// Los jobs creados antes de existir updated_at solo tienen started_at
+		query = query.Where("COALESCE(updated_at, started_at) < ?", staleBefore.UTC())
+	}
+	result := query.Updates(map[string]interface{}{
+		"status":      models.JobStatusFailed,
+		"finished_at": finishedAt,
+		"error":       "job interrumpido: el proceso terminó antes de completarlo",
+	})
+	return result.RowsAffected, result.Error
+}
+
+// QuarantineStocks guarda los registros rechazados durante la ingesta
+func QuarantineStocks(db *gorm.DB, rejected []models.QuarantinedStock) error {
+	return db.Create(&rejected).Error
+}
+
+// GetQuarantinedStocks obtiene los registros rechazados por un job de ingesta
+func GetQuarantinedStocks(db *gorm.DB, jobID int64, limit int) ([]models.QuarantinedStock, error) {
+	var rejected []models.QuarantinedStock
+
+	result := db.Where("job_id = ?", jobID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&rejected)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return rejected, nil
+}
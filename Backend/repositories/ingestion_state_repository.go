@@ -0,0 +1,37 @@
+package repositories
+
+import (
+	"errors"
+
+	"Backend/models"
+
+	"gorm.io/gorm"
+)
+
+// GetIngestionState obtiene el estado de ingesta de una fuente.
+// Si la fuente nunca se ha ingerido retorna un estado vacío.
+func GetIngestionState(db *gorm.DB, source string) (*models.IngestionState, error) {
+	var state models.IngestionState
+	err := db.Where("source = ?", source).First(&state).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.IngestionState{Source: source}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// SaveIngestionState persiste el estado de ingesta de una fuente
+func SaveIngestionState(db *gorm.DB, state *models.IngestionState) error {
+	return db.Save(state).Error
+}
+
+// GetIngestionStates obtiene el estado de ingesta de todas las fuentes
+func GetIngestionStates(db *gorm.DB) ([]models.IngestionState, error) {
+	var states []models.IngestionState
+	if err := db.Order("source ASC").Find(&states).Error; err != nil {
+		return nil, err
+	}
+	return states, nil
+}
@@ -0,0 +1,79 @@
+package repositories
+
+import (
+	"time"
+
+	"Backend/models"
+
+	"gorm.io/gorm"
+)
+
+// CreateRecommendationSnapshot guarda un snapshot junto con sus items
+func CreateRecommendationSnapshot(db *gorm.DB, snapshot *models.RecommendationSnapshot) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		items := snapshot.Items
+		snapshot.Items = nil
+		snapshot.ItemCount = len(items)
+		if err := tx.Create(snapshot).Error; err != nil {
+			return err
+		}
+
+		for i := range items {
+			items[i].SnapshotID = snapshot.ID
+		}
+		snapshot.Items = items
+		if len(items) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(&snapshot.Items, 500).Error
+	})
+}
+
+// GetRecommendationSnapshots obtiene los snapshots más recientes de una
+// estrategia, sin sus items
+func GetRecommendationSnapshots(db *gorm.DB, strategy string, limit int) ([]models.RecommendationSnapshot, error) {
+	var snapshots []models.RecommendationSnapshot
+	err := db.Where("strategy = ?", strategy).
+		Order("taken_at DESC, id DESC").
+		Limit(limit).
+		Find(&snapshots).Error
+	if err != nil {
+		return nil, err
+	}
+	return snapshots, nil
+}
+
+// GetRecommendationSnapshotBefore obtiene, con sus items, el último snapshot
+// de una estrategia tomado antes de before
+func GetRecommendationSnapshotBefore(db *gorm.DB, strategy string, before time.Time) (*models.RecommendationSnapshot, error) {
+	var snapshot models.RecommendationSnapshot
+	err := db.Where("strategy = ? AND taken_at < ?", strategy, before).
+		Order("taken_at DESC, id DESC").
+		Preload("Items", func(db *gorm.DB) *gorm.DB {
+			return db.Order("rank ASC")
+		}).
+		First(&snapshot).Error
+	if err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// DeleteRecommendationSnapshotsBefore elimina los snapshots tomados antes de
+// before junto con sus items y retorna cuántos snapshots se eliminaron
+func DeleteRecommendationSnapshotsBefore(db *gorm.DB, before time.Time) (int64, error) {
+	var deleted int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		old := tx.Model(&models.RecommendationSnapshot{}).Select("id").Where("taken_at < ?", before)
+		if err := tx.Where("snapshot_id IN (?)", old).Delete(&models.RecommendationSnapshotItem{}).Error; err != nil {
+			return err
+		}
+		result := tx.Where("taken_at < ?", before).Delete(&models.RecommendationSnapshot{})
+		if result.Error != nil {
+			return result.Error
+		}
+		deleted = result.RowsAffected
+		return nil
+	})
+	return deleted, err
+}
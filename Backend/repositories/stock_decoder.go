@@ -0,0 +1,123 @@
+package repositories
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"Backend/models"
+	"Backend/utils"
+)
+
+// StockDecodeError agrupa los errores de validación de un registro upstream
+type StockDecodeError struct {
+	Fields []string
+}
+
+func (e *StockDecodeError) Error() string {
+	return strings.Join(e.Fields, "; ")
+}
+
+// stockDecoder acumula los errores de campo mientras se decodifica un registro
+type stockDecoder struct {
+	item   map[string]interface{}
+	errors []string
+}
+
+// DecodeStockItem convierte un registro upstream en un models.Stock validando
+// cada campo. Los campos opcionales nulos se convierten en su valor cero;
+// ticker y time son obligatorios.
+func DecodeStockItem(item map[string]interface{}) (models.Stock, error) {
+	d := &stockDecoder{item: item}
+
+	stock := models.Stock{
+		Ticker:     d.requiredString("ticker", 10),
+		Company:    d.optionalString("company"),
+		TargetFrom: d.price("target_from"),
+		TargetTo:   d.price("target_to"),
+		Action:     d.optionalString("action"),
+		Brokerage:  d.optionalString("brokerage"),
+		RatingFrom: d.optionalString("rating_from"),
+		RatingTo:   d.optionalString("rating_to"),
+		Time:       d.timestamp("time"),
+	}
+
+	if len(d.errors) > 0 {
+		return models.Stock{}, &StockDecodeError{Fields: d.errors}
+	}
+	return stock, nil
+}
+
+func (d *stockDecoder) fail(field, format string, args ...interface{}) {
+	d.errors = append(d.errors, field+": "+fmt.Sprintf(format, args...))
+}
+
+func (d *stockDecoder) optionalString(field string) string {
+	value, exists := d.item[field]
+	if !exists || value == nil {
+		return ""
+	}
+	text, ok := value.(string)
+	if !ok {
+		d.fail(field, "se esperaba texto, se recibió %T", value)
+		return ""
+	}
+	return strings.TrimSpace(text)
+}
+
+func (d *stockDecoder) requiredString(field string, maxLength int) string {
+	value, exists := d.item[field]
+	if !exists || value == nil {
+		d.fail(field, "campo obligatorio ausente")
+		return ""
+	}
+	text := d.optionalString(field)
+	if _, ok := value.(string); ok {
+		if text == "" {
+			d.fail(field, "campo obligatorio vacío")
+		} else if len(text) > maxLength {
+			d.fail(field, "longitud máxima %d superada", maxLength)
+		}
+	}
+	return text
+}
+
+func (d *stockDecoder) price(field string) float64 {
+	switch value := d.item[field].(type) {
+	case nil:
+		return 0
+	case float64:
+		if math.IsNaN(value) || math.IsInf(value, 0) || value < 0 {
+			d.fail(field, "precio inválido %v", value)
+			return 0
+		}
+		return value
+	case string:
+		parsed, err := utils.ParsePriceStrict(value)
+		if err != nil {
+			d.fail(field, "%v", err)
+			return 0
+		}
+		if parsed < 0 {
+			d.fail(field, "precio negativo %v", parsed)
+			return 0
+		}
+		return parsed
+	default:
+		d.fail(field, "se esperaba un precio, se recibió %T", value)
+		return 0
+	}
+}
+
+func (d *stockDecoder) timestamp(field string) string {
+	text := d.requiredString(field, 64)
+	if text == "" {
+		return ""
+	}
+	if _, err := time.Parse(time.RFC3339Nano, text); err != nil {
+		d.fail(field, "fecha inválida %q, se esperaba RFC3339", text)
+		return ""
+	}
+	return text
+}
@@ -0,0 +1,56 @@
+package repositories
+
+import (
+	"time"
+
+	"Backend/models"
+
+	"gorm.io/gorm"
+)
+
+// StockHistoryQuery define los filtros y la paginación de la historia de un ticker.
+// From y To se comparan con la columna time como timestamps; To es exclusivo
+// y la fecha cero deja el límite abierto.
+type StockHistoryQuery struct {
+	Ticker   string
+	From     time.Time
+	To       time.Time
+	Page     int
+	PageSize int
+	Desc     bool
+}
+
+// GetStockHistory obtiene todos los eventos de un ticker en orden cronológico
+// junto con el total de eventos que cumplen los filtros
+func GetStockHistory(db *gorm.DB, q StockHistoryQuery) ([]models.Stock, int64, error) {
+	var stocks []models.Stock
+	var total int64
+
+	query := db.Model(&models.Stock{}).Where("UPPER(ticker) = ?", q.Ticker)
+	if !q.From.IsZero() {
+		query = query.Where("time::timestamptz >= ?", q.From)
+	}
+	if !q.To.IsZero() {
+		query = query.Where("time::timestamptz < ?", q.To)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	order := "time::timestamptz ASC, id ASC"
+	if q.Desc {
+		order = "time::timestamptz DESC, id DESC"
+	}
+
+	result := query.Order(order).
+		Offset((q.Page - 1) * q.PageSize).
+		Limit(q.PageSize).
+		Find(&stocks)
+
+	if result.Error != nil {
+		return nil, 0, result.Error
+	}
+
+	return stocks, total, nil
+}
@@ -1,20 +1,12 @@
 package repositories
 
 import (
-	"encoding/json"
-	"fmt"
-	"io"
-	"net/http"
-	"os"
 	"strings"
 	"time"
 
-	"Backend/config"
 	"Backend/models"
-	"Backend/utils"
 
 	"gorm.io/gorm"
-	"gorm.io/gorm/clause"
 )
 
 var db *gorm.DB
@@ -24,176 +16,258 @@ func SetDB(database *gorm.DB) {
 	db = database
 }
 
-func FetchAndStoreStockData() error {
-	var totalStocks int64
-	var nextPage string
-	var pageCount int
-	const maxPages = 20
-
-	for {
-		pageCount++
-		fmt.Printf(" Obteniendo página %d de %d...\n", pageCount, maxPages)
-
-		// Verificar si hemos alcanzado el límite de páginas
-		if pageCount >= maxPages {
-			fmt.Printf("Límite de %d páginas alcanzado\n", maxPages)
-			break
-		}
+// GetAllStocks obtiene todas las acciones de la base de datos, mostrando solo los registros más recientes por ticker.
+func GetAllStocks(db *gorm.DB) ([]models.Stock, error) {
+	var stocks []models.Stock
 
-		stockData, err := fetchStockData(nextPage)
-		if err != nil {
-			return fmt.Errorf("error fetching data from API: %v", err)
-		}
+	// Subconsulta para obtener el ID más reciente por ticker
+	subQuery := db.Model(&models.Stock{}).
+		Select("MAX(id) as id").
+		Group("ticker")
 
-		// Convertir los datos de la API a una lista de modelos Stock
-		var stocks []models.Stock
-		for _, item := range stockData.Items {
-			// Convertir el map[string]interface{} a los tipos correctos
-			stock := models.Stock{
-				Ticker:     item["ticker"].(string),
-				Company:    item["company"].(string),
-				TargetFrom: utils.ParsePrice(item["target_from"].(string)),
-				TargetTo:   utils.ParsePrice(item["target_to"].(string)),
-				Action:     item["action"].(string),
-				Brokerage:  item["brokerage"].(string),
-				RatingFrom: item["rating_from"].(string),
-				RatingTo:   item["rating_to"].(string),
-				Time:       item["time"].(string),
-			}
-			stocks = append(stocks, stock)
-		}
+	// Consulta principal que obtiene los registros más recientes
+	result := db.Where("id IN (?)", subQuery).
+		Order("time DESC").
+		Find(&stocks)
 
-		// Realizar un UPSERT usando GORM
-		result := db.Clauses(clause.OnConflict{
-			Columns:   []clause.Column{{Name: "ticker"}, {Name: "time"}},
-			DoUpdates: clause.AssignmentColumns([]string{"target_from", "target_to", "action", "brokerage", "rating_from", "rating_to"}),
-		}).Create(&stocks)
+	if result.Error != nil {
+		return nil, result.Error
+	}
 
-		if result.Error != nil {
-			return fmt.Errorf("error inserting/updating stocks: %v", result.Error)
-		}
+	return stocks, nil
+}
 
-		totalStocks += result.RowsAffected
+// GetLatestStocksPerBrokerage obtiene la nota más reciente de cada brokerage
+// por ticker, opcionalmente solo de los tickers indicados
+func GetLatestStocksPerBrokerage(db *gorm.DB, tickers ...string) ([]models.Stock, error) {
+	var stocks []models.Stock
 
-		// Verificar si hay más páginas
-		nextPage = stockData.NextPage
-		if nextPage == "" {
-			fmt.Printf("Ultima Pagina\n")
-			break
-		}
+	subQuery := whereIn(db.Model(&models.Stock{}), "UPPER(ticker)", upper(tickers), false).
+		Select("MAX(id) as id").
+		Group("ticker, brokerage")
 
-		// Pequeña pausa para no sobrecargar la API
-		time.Sleep(1 * time.Second)
+	result := db.Where("id IN (?)", subQuery).
+		Order("ticker, time DESC").
+		Find(&stocks)
+	if result.Error != nil {
+		return nil, result.Error
 	}
-
-	fmt.Printf("✅ Proceso completado:\n")
-	fmt.Printf("   - Total de stocks procesados: %d\n", totalStocks)
-	return nil
+	return stocks, nil
 }
 
-func fetchStockData(nextPage string) (*models.StockResponse, error) {
-	apiURL := os.Getenv("API_URL")
-	apiKey := os.Getenv("API_KEY")
-
-	// Construir la URL con el parámetro next_page si existe
-	if nextPage != "" {
-		// Verificar si la URL ya tiene un parámetro
-		if strings.Contains(apiURL, "?") {
-			apiURL = fmt.Sprintf("%s&next_page=%s", apiURL, nextPage)
-		} else {
-			apiURL = fmt.Sprintf("%s?next_page=%s", apiURL, nextPage)
-		}
-	}
-
-	// Crear una nueva solicitud HTTP
-	req, err := http.NewRequest("GET", apiURL, nil)
+// GetLatestStock obtiene el registro más reciente de un ticker
+func GetLatestStock(db *gorm.DB, ticker string) (*models.Stock, error) {
+	var stock models.Stock
+	err := db.Where("UPPER(ticker) = ?", strings.ToUpper(ticker)).
+		Order("id DESC").
+		First(&stock).Error
 	if err != nil {
-		return nil, fmt.Errorf("error creating HTTP request: %v", err)
+		return nil, err
 	}
+	return &stock, nil
+}
 
-	// Agregar headers
-	req.Header.Set("Authorization", "Bearer "+apiKey)
-	req.Header.Set("Content-Type", "application/json")
-
-	// Usar el cliente HTTP compartido
-	client := config.GetHTTPClient()
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("error making HTTP request: %v", err)
+// GetRecentStocks obtiene todos los eventos con fecha igual o posterior a
+// since (todos si since es la fecha cero), opcionalmente solo de los tickers
+// indicados
+func GetRecentStocks(db *gorm.DB, since time.Time, tickers ...string) ([]models.Stock, error) {
+	var stocks []models.Stock
+	query := db
+	if !since.IsZero() {
+		// La columna time es texto: compararla como cadena falla con zonas
+		// horarias o fracciones de segundo de distinta longitud
+		query = query.Where("time::timestamptz >= ?", since)
 	}
-	defer resp.Body.Close()
-
-	// Leer la respuesta
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("error reading response body: %v", err)
+	query = whereIn(query, "UPPER(ticker)", upper(tickers), false)
+	if err := query.Order("ticker, time DESC").Find(&stocks).Error; err != nil {
+		return nil, err
 	}
+	return stocks, nil
+}
 
-	// Verificar el código de estado
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API error: %s", resp.Status)
-	}
+// StockFilters define los filtros de una consulta de stocks. Las listas se
+// combinan con OR dentro del mismo filtro y los filtros entre sí con AND; los
+// campos Exclude* excluyen los valores indicados. Tickers, acciones y
+// calificaciones se comparan sin distinguir mayúsculas; brokerages y company
+// por coincidencia parcial.
+type StockFilters struct {
+	Tickers           []string
+	ExcludeTickers    []string
+	Company           string
+	Brokerages        []string
+	ExcludeBrokerages []string
+	Actions           []string
+	ExcludeActions    []string
+	RatingsFrom       []string
+	ExcludeRatingFrom []string
+	RatingsTo         []string
+	ExcludeRatingTo   []string
+
+	TargetFromMin      *float64
+	TargetFromMax      *float64
+	TargetToMin        *float64
+	TargetToMax        *float64
+	TargetChangePctMin *float64
+	TargetChangePctMax *float64
+
+	// TimeFrom es inclusivo y TimeTo exclusivo; la fecha cero deja el límite abierto
+	TimeFrom time.Time
+	TimeTo   time.Time
+}
 
-	// Parsear la respuesta JSON
-	var stockData models.StockResponse
-	if err := json.Unmarshal(body, &stockData); err != nil {
-		return nil, fmt.Errorf("error parsing API response: %v", err)
-	}
+// StockQuery define los filtros, el orden, la paginación y los campos de una consulta de stocks.
+// Si PageSize es 0 se retornan todos los registros.
+type StockQuery struct {
+	Filters StockFilters
 
-	return &stockData, nil
+	Page     int
+	PageSize int
+	SortBy   string
+	SortDesc bool
+	Fields   []string
 }
 
-// GetAllStocks obtiene todas las acciones de la base de datos, mostrando solo los registros más recientes por ticker.
-func GetAllStocks(db *gorm.DB) ([]models.Stock, error) {
-	var stocks []models.Stock
+// StockPage contiene una página de resultados junto con el total de registros
+// que cumplen los filtros y la fecha del evento más reciente entre ellos
+type StockPage struct {
+	Stocks     []models.Stock
+	Total      int64
+	LastUpdate string
+}
 
-	// Subconsulta para obtener el ID más reciente por ticker
-	subQuery := db.Model(&models.Stock{}).
+// GetStocks obtiene las acciones filtradas, mostrando por ticker el evento más
+// reciente que cumple los filtros. Los filtros se aplican sobre todos los
+// eventos antes de elegir uno por ticker, de modo que un ticker aparece aunque
+// su último evento no los cumpla. SortBy y Fields deben ser campos de models.StockFields.
+func GetStocks(db *gorm.DB, q StockQuery) (*StockPage, error) {
+	var page StockPage
+
+	// Subconsulta para obtener el ID más reciente por ticker entre los eventos filtrados
+	subQuery := applyStockFilters(db.Model(&models.Stock{}), q.Filters).
 		Select("MAX(id) as id").
 		Group("ticker")
 
-	// Consulta principal que obtiene los registros más recientes
-	result := db.Where("id IN (?)", subQuery).
-		Order("time DESC").
-		Find(&stocks)
+	// Consulta base con los registros más recientes
+	query := db.Model(&models.Stock{}).Where("id IN (?)", subQuery)
 
-	if result.Error != nil {
-		return nil, result.Error
+	if err := query.Session(&gorm.Session{}).Count(&page.Total).Error; err != nil {
+		return nil, err
+	}
+	if page.Total == 0 {
+		return &page, nil
 	}
 
-	return stocks, nil
-}
+	var lastUpdate *string
+	if err := query.Session(&gorm.Session{}).Select("MAX(time)").Scan(&lastUpdate).Error; err != nil {
+		return nil, err
+	}
+	if lastUpdate != nil {
+		page.LastUpdate = *lastUpdate
+	}
 
-// GetStocks obtiene las acciones filtradas por ticker, company y brokerage, mostrando solo los registros más recientes.
-func GetStocks(db *gorm.DB, ticker, company, brokerage string) ([]models.Stock, error) {
-	var stocks []models.Stock
+	// Ordenar por la columna solicitada, por defecto fecha descendente,
+	// con el id como desempate para que la paginación sea estable
+	sortBy, direction := "time", "DESC"
+	if q.SortBy != "" {
+		sortBy = q.SortBy
+		direction = "ASC"
+		if q.SortDesc {
+			direction = "DESC"
+		}
+	}
+	query = query.Order(sortBy + " " + direction).Order("id " + direction)
 
-	// Subconsulta para obtener el ID más reciente por ticker
-	subQuery := db.Model(&models.Stock{}).
-		Select("MAX(id) as id").
-		Group("ticker")
+	if len(q.Fields) > 0 {
+		query = query.Select(q.Fields)
+	}
+	if q.PageSize > 0 {
+		query = query.Offset((q.Page - 1) * q.PageSize).Limit(q.PageSize)
+	}
 
-	// Consulta base con los registros más recientes
-	query := db.Where("id IN (?)", subQuery)
+	if err := query.Find(&page.Stocks).Error; err != nil {
+		return nil, err
+	}
 
-	if ticker != "" {
-		query = query.Where("ticker = ?", ticker)
+	return &page, nil
+}
+
+// targetChangePctExpr calcula el cambio porcentual del precio objetivo,
+// nulo cuando no hay precio objetivo anterior
+const targetChangePctExpr = "(target_to - target_from) / NULLIF(target_from, 0) * 100"
+
+// applyStockFilters agrega las condiciones de los filtros a la consulta
+func applyStockFilters(query *gorm.DB, f StockFilters) *gorm.DB {
+	query = whereIn(query, "UPPER(ticker)", upper(f.Tickers), false)
+	query = whereIn(query, "UPPER(ticker)", upper(f.ExcludeTickers), true)
+	query = whereIn(query, "LOWER(action)", lower(f.Actions), false)
+	query = whereIn(query, "LOWER(action)", lower(f.ExcludeActions), true)
+	query = whereIn(query, "LOWER(rating_from)", lower(f.RatingsFrom), false)
+	query = whereIn(query, "LOWER(rating_from)", lower(f.ExcludeRatingFrom), true)
+	query = whereIn(query, "LOWER(rating_to)", lower(f.RatingsTo), false)
+	query = whereIn(query, "LOWER(rating_to)", lower(f.ExcludeRatingTo), true)
+
+	if f.Company != "" {
+		query = query.Where("company ILIKE ?", "%"+f.Company+"%")
 	}
-	if company != "" {
-		query = query.Where("company ILIKE ?", "%"+company+"%")
+	if len(f.Brokerages) > 0 {
+		conditions := make([]string, 0, len(f.Brokerages))
+		args := make([]interface{}, 0, len(f.Brokerages))
+		for _, brokerage := range f.Brokerages {
+			conditions = append(conditions, "brokerage ILIKE ?")
+			args = append(args, "%"+brokerage+"%")
+		}
+		query = query.Where("("+strings.Join(conditions, " OR ")+")", args...)
 	}
-	if brokerage != "" {
-		query = query.Where("brokerage ILIKE ?", "%"+brokerage+"%")
+	for _, brokerage := range f.ExcludeBrokerages {
+		query = query.Where("brokerage NOT ILIKE ?", "%"+brokerage+"%")
 	}
 
-	// Ordenar por fecha descendente
-	query = query.Order("time DESC")
+	query = whereRange(query, "target_from", f.TargetFromMin, f.TargetFromMax)
+	query = whereRange(query, "target_to", f.TargetToMin, f.TargetToMax)
+	query = whereRange(query, targetChangePctExpr, f.TargetChangePctMin, f.TargetChangePctMax)
 
-	result := query.Find(&stocks)
+	if !f.TimeFrom.IsZero() {
+		query = query.Where("time::timestamptz >= ?", f.TimeFrom)
+	}
+	if !f.TimeTo.IsZero() {
+		query = query.Where("time::timestamptz < ?", f.TimeTo)
+	}
 
-	if result.Error != nil {
-		return nil, result.Error
+	return query
+}
+
+func whereIn(query *gorm.DB, column string, values []string, exclude bool) *gorm.DB {
+	if len(values) == 0 {
+		return query
 	}
+	if exclude {
+		return query.Where(column+" NOT IN ?", values)
+	}
+	return query.Where(column+" IN ?", values)
+}
 
-	return stocks, nil
+func whereRange(query *gorm.DB, expr string, min, max *float64) *gorm.DB {
+	if min != nil {
+		query = query.Where(expr+" >= ?", *min)
+	}
+	if max != nil {
+		query = query.Where(expr+" <= ?", *max)
+	}
+	return query
+}
+
+func upper(values []string) []string {
+	result := make([]string, len(values))
+	for i, value := range values {
+		result[i] = strings.ToUpper(value)
+	}
+	return result
+}
+
+func lower(values []string) []string {
+	result := make([]string, len(values))
+	for i, value := range values {
+		result[i] = strings.ToLower(value)
+	}
+	return result
 }
@@ -0,0 +1,267 @@
+package repositories
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"Backend/config"
+	"Backend/models"
+)
+
+// StockSource define una fuente de datos de la que se ingieren los stocks.
+// La paginación se hace por cursor: un cursor vacío pide la primera página y
+// el campo NextPage de la respuesta indica el cursor de la siguiente; si viene
+// vacío no hay más páginas.
+type StockSource interface {
+	// Name devuelve un identificador corto y estable de la fuente
+	Name() string
+	// Describe devuelve una descripción legible de la fuente para logs
+	Describe() string
+	// FetchPage obtiene la página correspondiente al cursor
+	FetchPage(ctx context.Context, cursor string) (*models.StockResponse, error)
+}
+
+// NewStockSource crea la fuente de datos indicada en la configuración
+func NewStockSource(cfg *config.SourceConfig) (StockSource, error) {
+	switch cfg.Type {
+	case config.SourceTypeHTTP:
+		if cfg.APIURL == "" {
+			return nil, fmt.Errorf("API_URL is required for source %q", cfg.Type)
+		}
+		return NewHTTPStockSource(cfg.APIURL, cfg.APIKey), nil
+	case config.SourceTypeFile:
+		if cfg.FilePath == "" {
+			return nil, fmt.Errorf("STOCK_SOURCE_PATH is required for source %q", cfg.Type)
+		}
+		return NewFileStockSource(cfg.FilePath, cfg.PageSize), nil
+	default:
+		return nil, fmt.Errorf("unknown stock source %q", cfg.Type)
+	}
+}
+
+// HTTPStockSource obtiene los stocks de la API HTTP upstream
+type HTTPStockSource struct {
+	URL    string
+	APIKey string
+	Client *http.Client
+}
+
+// NewHTTPStockSource crea una fuente HTTP que usa el cliente HTTP compartido
+func NewHTTPStockSource(apiURL, apiKey string) *HTTPStockSource {
+	return &HTTPStockSource{
+		URL:    apiURL,
+		APIKey: apiKey,
+		Client: config.GetHTTPClient(),
+	}
+}
+
+// Name implementa StockSource
+func (s *HTTPStockSource) Name() string {
+	return config.SourceTypeHTTP
+}
+
+// Describe implementa StockSource sin exponer parámetros de la URL
+func (s *HTTPStockSource) Describe() string {
+	parsed, err := url.Parse(s.URL)
+	if err != nil {
+		return "http"
+	}
+	return fmt.Sprintf("http %s://%s%s", parsed.Scheme, parsed.Host, parsed.Path)
+}
+
+// FetchPage implementa StockSource
+func (s *HTTPStockSource) FetchPage(ctx context.Context, cursor string) (*models.StockResponse, error) {
+	apiURL := s.URL
+
+	// Construir la URL con el parámetro next_page si existe
+	if cursor != "" {
+		// Verificar si la URL ya tiene un parámetro
+		if strings.Contains(apiURL, "?") {
+			apiURL = fmt.Sprintf("%s&next_page=%s", apiURL, url.QueryEscape(cursor))
+		} else {
+			apiURL = fmt.Sprintf("%s?next_page=%s", apiURL, url.QueryEscape(cursor))
+		}
+	}
+
+	// Crear una nueva solicitud HTTP
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating HTTP request: %v", err)
+	}
+
+	// Agregar headers
+	req.Header.Set("Authorization", "Bearer "+s.APIKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making HTTP request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	// Leer la respuesta
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response body: %v", err)
+	}
+
+	// Verificar el código de estado
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API error: %s", resp.Status)
+	}
+
+	// Parsear la respuesta JSON
+	var stockData models.StockResponse
+	if err := json.Unmarshal(body, &stockData); err != nil {
+		return nil, fmt.Errorf("error parsing API response: %v", err)
+	}
+
+	return &stockData, nil
+}
+
+// FileStockSource lee los stocks de un archivo local JSON o CSV.
+// El JSON puede ser una lista de items o un objeto con el formato de la API
+// ({"items": [...]}); el CSV debe tener una cabecera con los nombres de campo.
+// El cursor es el desplazamiento dentro del archivo.
+type FileStockSource struct {
+	Path     string
+	PageSize int
+
+	once  sync.Once
+	items []map[string]interface{}
+	err   error
+}
+
+// NewFileStockSource crea una fuente que lee el archivo indicado
+func NewFileStockSource(path string, pageSize int) *FileStockSource {
+	if pageSize <= 0 {
+		pageSize = 100
+	}
+	return &FileStockSource{Path: path, PageSize: pageSize}
+}
+
+// Name implementa StockSource
+func (s *FileStockSource) Name() string {
+	return config.SourceTypeFile + ":" + filepath.Base(s.Path)
+}
+
+// Describe implementa StockSource
+func (s *FileStockSource) Describe() string {
+	return fmt.Sprintf("file %s", s.Path)
+}
+
+// FetchPage implementa StockSource
+func (s *FileStockSource) FetchPage(ctx context.Context, cursor string) (*models.StockResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.once.Do(func() {
+		s.items, s.err = s.load()
+	})
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	offset := 0
+	if cursor != "" {
+		value, err := strconv.Atoi(cursor)
+		if err != nil || value < 0 {
+			return nil, fmt.Errorf("invalid file cursor %q", cursor)
+		}
+		offset = value
+	}
+	if offset > len(s.items) {
+		offset = len(s.items)
+	}
+
+	end := offset + s.PageSize
+	if end > len(s.items) {
+		end = len(s.items)
+	}
+
+	response := &models.StockResponse{Items: s.items[offset:end]}
+	if end < len(s.items) {
+		response.NextPage = strconv.Itoa(end)
+	}
+	return response, nil
+}
+
+func (s *FileStockSource) load() ([]map[string]interface{}, error) {
+	file, err := os.Open(s.Path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening source file: %v", err)
+	}
+	defer file.Close()
+
+	switch strings.ToLower(filepath.Ext(s.Path)) {
+	case ".json":
+		return loadJSONItems(file)
+	case ".csv":
+		return loadCSVItems(file)
+	default:
+		return nil, fmt.Errorf("unsupported source file extension %q", filepath.Ext(s.Path))
+	}
+}
+
+func loadJSONItems(r io.Reader) ([]map[string]interface{}, error) {
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("error reading source file: %v", err)
+	}
+
+	trimmed := strings.TrimSpace(string(body))
+	if strings.HasPrefix(trimmed, "[") {
+		var items []map[string]interface{}
+		if err := json.Unmarshal(body, &items); err != nil {
+			return nil, fmt.Errorf("error parsing source file: %v", err)
+		}
+		return items, nil
+	}
+
+	var stockData models.StockResponse
+	if err := json.Unmarshal(body, &stockData); err != nil {
+		return nil, fmt.Errorf("error parsing source file: %v", err)
+	}
+	return stockData.Items, nil
+}
+
+func loadCSVItems(r io.Reader) ([]map[string]interface{}, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("error parsing source file: %v", err)
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	header := records[0]
+	for i := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(header[i]))
+	}
+
+	items := make([]map[string]interface{}, 0, len(records)-1)
+	for _, record := range records[1:] {
+		item := make(map[string]interface{}, len(header))
+		for i, field := range header {
+			if i < len(record) {
+				item[field] = record[i]
+			}
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
@@ -0,0 +1,111 @@
+package repositories
+
+import (
+	"time"
+
+	"Backend/models"
+
+	"gorm.io/gorm"
+)
+
+// GetUserByUsername obtiene un usuario por su nombre, sin distinguir mayúsculas
+func GetUserByUsername(db *gorm.DB, username string) (*models.User, error) {
+	var user models.User
+	if err := db.Where("LOWER(username) = LOWER(?)", username).First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetUser obtiene un usuario por su ID
+func GetUser(db *gorm.DB, id int64) (*models.User, error) {
+	var user models.User
+	if err := db.First(&user, id).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// CreateUser guarda un usuario nuevo
+func CreateUser(db *gorm.DB, user *models.User) error {
+	return db.Create(user).Error
+}
+
+// GetUsers obtiene todos los usuarios ordenados por nombre
+func GetUsers(db *gorm.DB) ([]models.User, error) {
+	var users []models.User
+	if err := db.Order("username ASC").Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// SaveUser guarda todos los campos de un usuario existente
+func SaveUser(db *gorm.DB, user *models.User) error {
+	return db.Save(user).Error
+}
+
+// DeleteUser elimina un usuario y sus refresh tokens. Retorna
+// gorm.ErrRecordNotFound si no existía.
+func DeleteUser(db *gorm.DB, id int64) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", id).Delete(&models.RefreshToken{}).Error; err != nil {
+			return err
+		}
+		if err := RevokeUserAPIKeys(tx, id, time.Now()); err != nil {
+			return err
+		}
+		result := tx.Delete(&models.User{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+// CountActiveUsersWithRole retorna la cantidad de usuarios activos con un rol
+func CountActiveUsersWithRole(db *gorm.DB, role string) (int64, error) {
+	var count int64
+	err := db.Model(&models.User{}).Where("role = ? AND active = ?", role, true).Count(&count).Error
+	return count, err
+}
+
+// CreateRefreshToken guarda un refresh token
+func CreateRefreshToken(db *gorm.DB, token *models.RefreshToken) error {
+	return db.Create(token).Error
+}
+
+// GetRefreshToken obtiene un refresh token por su hash
+func GetRefreshToken(db *gorm.DB, tokenHash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	if err := db.Where("token_hash = ?", tokenHash).First(&token).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// RevokeRefreshToken marca un refresh token como usado. Retorna
+// gorm.ErrRecordNotFound si no existía o ya estaba revocado, de modo que un
+// mismo token no se puede usar dos veces aunque lleguen peticiones en paralelo.
+func RevokeRefreshToken(db *gorm.DB, id int64, at time.Time) error {
+	result := db.Model(&models.RefreshToken{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", at)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// RevokeUserRefreshTokens revoca todos los refresh tokens vigentes de un usuario
+func RevokeUserRefreshTokens(db *gorm.DB, userID int64, at time.Time) error {
+	return db.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", at).Error
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"Backend/config"
+	"Backend/models"
+	"Backend/repositories"
+
+	"gorm.io/gorm"
+)
+
+// apiKeyPrefix identifica las API keys emitidas por esta aplicación
+const apiKeyPrefix = "stk_"
+
+// apiKeyTouchInterval es cada cuánto se guarda el último uso de una API key,
+// para no escribir en la base de datos en cada petición
+const apiKeyTouchInterval = time.Minute
+
+// apiKeyForbiddenScopes son los permisos que no se pueden delegar en una API
+// key: gestionar usuarios o claves exige la sesión de una persona
+var apiKeyForbiddenScopes = []string{PermissionManageUsers, PermissionManageAPIKeys}
+
+// ErrInvalidAPIKey indica una API key desconocida, revocada o vencida
+var ErrInvalidAPIKey = errors.New("API key inválida, revocada o vencida")
+
+// NewAPIKeyRequest define una API key a crear
+type NewAPIKeyRequest struct {
+	Name string
+	// Scopes son los permisos de la clave; deben ser permisos del rol de quien
+	// la crea y no pueden incluir la gestión de usuarios ni de API keys
+	Scopes []string
+	// RateLimit es una regla "<peticiones>/<duración>"; vacío usa el límite
+	// del grupo de rutas
+	RateLimit string
+	ExpiresAt *time.Time
+	CreatedBy *int64
+	// CreatorRole es el rol de quien crea la clave
+	CreatorRole string
+}
+
+// ValidateAPIKeyRequest verifica el nombre, los scopes, el límite y el
+// vencimiento de una API key a crear
+func ValidateAPIKeyRequest(req NewAPIKeyRequest, now time.Time) error {
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len(name) > 100 {
+		return errors.New("name debe tener entre 1 y 100 caracteres")
+	}
+	if len(req.Scopes) == 0 {
+		return errors.New("la API key necesita al menos un scope")
+	}
+	for _, scope := range req.Scopes {
+		if slices.Contains(apiKeyForbiddenScopes, scope) {
+			return fmt.Errorf("el scope %q no se puede asignar a una API key", scope)
+		}
+		if !HasPermission(req.CreatorRole, scope) {
+			return fmt.Errorf("scope %q desconocido o no permitido para el rol %q", scope, req.CreatorRole)
+		}
+	}
+	if req.RateLimit != "" {
+		if _, err := config.ParseRateLimitRule(req.RateLimit); err != nil {
+			return err
+		}
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(now) {
+		return errors.New("expires_at debe ser una fecha futura")
+	}
+	return nil
+}
+
+// CreateAPIKey genera una API key y guarda su hash. Retorna la clave en claro,
+// que no se puede volver a obtener.
+func CreateAPIKey(db *gorm.DB, req NewAPIKeyRequest, now time.Time) (*models.APIKey, string, error) {
+	if err := ValidateAPIKeyRequest(req, now); err != nil {
+		return nil, "", err
+	}
+	scopes := slices.Clone(req.Scopes)
+	slices.Sort(scopes)
+	scopes = slices.Compact(scopes)
+
+	secret, err := randomToken()
+	if err != nil {
+		return nil, "", err
+	}
+	plain := apiKeyPrefix + secret
+
+	key := &models.APIKey{
+		Name:      strings.TrimSpace(req.Name),
+		Prefix:    plain[:len(apiKeyPrefix)+6],
+		KeyHash:   hashToken(plain),
+		Scopes:    scopes,
+		RateLimit: req.RateLimit,
+		CreatedBy: req.CreatedBy,
+		ExpiresAt: req.ExpiresAt,
+	}
+	if err := repositories.CreateAPIKey(db, key); err != nil {
+		return nil, "", err
+	}
+	return key, plain, nil
+}
+
+// VerifyAPIKey obtiene la API key vigente que corresponde a la clave en claro
+// y registra su uso
+func VerifyAPIKey(db *gorm.DB, plain string, now time.Time) (*models.APIKey, error) {
+	if !strings.HasPrefix(plain, apiKeyPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	key, err := repositories.GetAPIKeyByHash(db, hashToken(plain))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, err
+	}
+	if key.RevokedAt != nil || (key.ExpiresAt != nil && !now.Before(*key.ExpiresAt)) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyTouchInterval {
+		if err := repositories.TouchAPIKey(db, key.ID, now); err != nil {
+			config.LogError(err, "VerifyAPIKey")
+		} else {
+			key.LastUsedAt = &now
+		}
+	}
+	return key, nil
+}
+
+// APIKeyScopes retorna los scopes que un rol puede asignar a una API key
+func APIKeyScopes(role string) []string {
+	var scopes []string
+	for _, permission := range RolePermissions(role) {
+		if !slices.Contains(apiKeyForbiddenScopes, permission) {
+			scopes = append(scopes, permission)
+		}
+	}
+	return scopes
+}
+
+// APIKeyAllows indica si una API key tiene un permiso. Los permisos que no se
+// pueden delegar se rechazan aunque la clave los tenga.
+func APIKeyAllows(key *models.APIKey, permission string) bool {
+	return slices.Contains(key.Scopes, permission) && !slices.Contains(apiKeyForbiddenScopes, permission)
+}
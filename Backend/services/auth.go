@@ -0,0 +1,240 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"Backend/config"
+	"Backend/models"
+	"Backend/repositories"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// MinPasswordLength es la longitud mínima de una contraseña
+const MinPasswordLength = 8
+
+// ErrInvalidCredentials indica un usuario inexistente, inactivo o una
+// contraseña incorrecta; no se distingue cuál para no revelar usuarios
+var ErrInvalidCredentials = errors.New("usuario o contraseña incorrectos")
+
+// dummyPasswordHash se compara cuando el usuario no existe para que la
+// respuesta tarde lo mismo que con un usuario válido
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("dummy-password"), bcrypt.DefaultCost)
+
+// TokenPair es la respuesta de login y refresh
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	// ExpiresIn es la vigencia del access token en segundos
+	ExpiresIn int64 `json:"expires_in"`
+}
+
+// ValidatePassword verifica que una contraseña se pueda usar
+func ValidatePassword(password string) error {
+	if len(password) < MinPasswordLength {
+		return fmt.Errorf("la contraseña debe tener al menos %d caracteres", MinPasswordLength)
+	}
+	// bcrypt solo usa los primeros 72 bytes
+	if len(password) > 72 {
+		return errors.New("la contraseña no puede superar 72 bytes")
+	}
+	return nil
+}
+
+// HashPassword genera el hash bcrypt de una contraseña
+func HashPassword(password string) (string, error) {
+	if err := ValidatePassword(password); err != nil {
+		return "", err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// Authenticate verifica el usuario y la contraseña
+func Authenticate(db *gorm.DB, username, password string) (*models.User, error) {
+	user, err := repositories.GetUserByUsername(db, strings.TrimSpace(username))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(password))
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	if !user.Active {
+		return nil, ErrInvalidCredentials
+	}
+	return user, nil
+}
+
+// IssueTokens emite un access token firmado y un refresh token nuevo para el
+// usuario
+func IssueTokens(db *gorm.DB, user *models.User, cfg *config.SecurityConfig, now time.Time) (*TokenPair, error) {
+	claims := TokenClaims{
+		Subject:   strconv.FormatInt(user.ID, 10),
+		Username:  user.Username,
+		Role:      user.Role,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(cfg.TokenDuration).Unix(),
+	}
+	accessToken, err := SignToken(claims, cfg.JWTSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	err = repositories.CreateRefreshToken(db, &models.RefreshToken{
+		UserID:    user.ID,
+		TokenHash: hashToken(refreshToken),
+		ExpiresAt: now.Add(cfg.RefreshTokenDuration),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(cfg.TokenDuration.Seconds()),
+	}, nil
+}
+
+// RefreshTokens canjea un refresh token por un nuevo par de tokens. Cada
+// refresh token se puede usar una sola vez; si se reutiliza uno ya usado se
+// revocan todos los del usuario, porque probablemente fue robado.
+func RefreshTokens(db *gorm.DB, refreshToken string, cfg *config.SecurityConfig, now time.Time) (*TokenPair, error) {
+	stored, err := repositories.GetRefreshToken(db, hashToken(refreshToken))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if stored.RevokedAt != nil {
+		if err := repositories.RevokeUserRefreshTokens(db, stored.UserID, now); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidToken
+	}
+	if !now.Before(stored.ExpiresAt) {
+		return nil, ErrInvalidToken
+	}
+	if err := repositories.RevokeRefreshToken(db, stored.ID, now); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidToken
+		}
+		return nil, err
+	}
+
+	user, err := repositories.GetUser(db, stored.UserID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !user.Active {
+		return nil, ErrInvalidToken
+	}
+	return IssueTokens(db, user, cfg, now)
+}
+
+// RevokeRefreshToken invalida un refresh token al cerrar sesión. Un token
+// desconocido o ya revocado no es un error.
+func RevokeRefreshToken(db *gorm.DB, refreshToken string, now time.Time) error {
+	stored, err := repositories.GetRefreshToken(db, hashToken(refreshToken))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := repositories.RevokeRefreshToken(db, stored.ID, now); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
+
+// ErrBootstrapPasswordMismatch indica que ADMIN_USERNAME corresponde a un
+// usuario existente cuya contraseña no coincide con ADMIN_PASSWORD
+var ErrBootstrapPasswordMismatch = errors.New("ADMIN_PASSWORD no coincide con la contraseña del usuario existente; no se promovió a administrador")
+
+// BootstrapAdmin crea un administrador con username y password si no hay
+// ninguno activo. Si el usuario ya existe solo lo convierte en administrador
+// cuando password coincide con su contraseña; en otro caso retorna
+// ErrBootstrapPasswordMismatch. Retorna true si creó o modificó el usuario.
+func BootstrapAdmin(db *gorm.DB, username, password string) (bool, error) {
+	username = strings.TrimSpace(username)
+	if username == "" || password == "" {
+		return false, nil
+	}
+
+	admins, err := repositories.CountActiveUsersWithRole(db, models.RoleAdmin)
+	if err != nil {
+		return false, err
+	}
+	if admins > 0 {
+		return false, nil
+	}
+
+	user, err := repositories.GetUserByUsername(db, username)
+	if err == nil {
+		// Sin verificar la contraseña, bastaría con poner el nombre de otro
+		// usuario en ADMIN_USERNAME para darle acceso de administrador
+		if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
+			return false, ErrBootstrapPasswordMismatch
+		}
+		user.Role = models.RoleAdmin
+		user.Active = true
+		return true, repositories.SaveUser(db, user)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, err
+	}
+
+	hash, err := HashPassword(password)
+	if err != nil {
+		return false, err
+	}
+	user = &models.User{Username: username, PasswordHash: hash, Role: models.RoleAdmin, Active: true}
+	if err := repositories.CreateUser(db, user); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// randomToken genera un token aleatorio opaco
+func randomToken() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+// hashToken retorna el hash SHA-256 con el que se guarda un token
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
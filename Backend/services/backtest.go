@@ -0,0 +1,365 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"Backend/models"
+)
+
+// MaxBacktestDays limita la cantidad de días que se reproducen en un backtest
+const MaxBacktestDays = 366
+
+// MaxBacktestAPIDays limita los días de un backtest pedido por la API, que se
+// ejecuta durante la petición; los rangos más largos se ejecutan con el CLI
+const MaxBacktestAPIDays = 90
+
+// BacktestOptions define un backtest
+type BacktestOptions struct {
+	Strategy *ScoringStrategy
+	Brokers  BrokerScorer
+	// From y To son el primer y el último día reproducidos (UTC)
+	From time.Time
+	To   time.Time
+	// Horizon es el plazo tras cada día en el que se miden las notas siguientes
+	Horizon time.Duration
+	// TopN limita la evaluación a las N mejores recomendaciones de cada día; 0
+	// evalúa todas
+	TopN int
+}
+
+// BacktestBand acumula el resultado de las recomendaciones de un tramo
+type BacktestBand struct {
+	Key   string `json:"key"`
+	Label string `json:"label"`
+	// Recommendations es la cantidad de recomendaciones del tramo
+	Recommendations int `json:"recommendations"`
+	// Evaluated son las que tuvieron al menos una nota posterior dentro del horizonte
+	Evaluated int `json:"evaluated"`
+	// Positive y Negative cuentan las evaluadas cuyas notas siguientes fueron
+	// mayoritariamente subidas o bajadas de calificación o precio objetivo
+	Positive     int     `json:"positive"`
+	Negative     int     `json:"negative"`
+	PositiveRate float64 `json:"positive_rate"`
+	NegativeRate float64 `json:"negative_rate"`
+	// AvgTargetChangePct es el cambio medio del precio objetivo dentro del horizonte
+	AvgTargetChangePct float64 `json:"avg_target_change_pct"`
+
+	targetChanges int
+}
+
+// BacktestDay resume las recomendaciones de un día
+type BacktestDay struct {
+	Date            string  `json:"date"`
+	Recommendations int     `json:"recommendations"`
+	Evaluated       int     `json:"evaluated"`
+	Correlation     float64 `json:"correlation"`
+}
+
+// BacktestReport es el resultado de un backtest
+type BacktestReport struct {
+	Strategy     string  `json:"strategy"`
+	From         string  `json:"from"`
+	To           string  `json:"to"`
+	HorizonHours float64 `json:"horizon_hours"`
+	TopN         int     `json:"top_n"`
+	// Events es la cantidad de eventos del historial usados
+	Events          int `json:"events"`
+	Recommendations int `json:"recommendations"`
+	Evaluated       int `json:"evaluated"`
+	// Correlation es la correlación entre el puntaje y el balance de notas
+	// siguientes (subidas menos bajadas) de todas las recomendaciones evaluadas;
+	// un valor positivo indica que los puntajes altos anticipan subidas
+	Correlation float64         `json:"correlation"`
+	Bands       []*BacktestBand `json:"bands"`
+	Days        []BacktestDay   `json:"days"`
+}
+
+// backtestEvent es un evento con su fecha interpretada
+type backtestEvent struct {
+	stock models.Stock
+	time  time.Time
+}
+
+// RunBacktest reproduce el historial de eventos día a día: al final de cada día
+// calcula las recomendaciones que se habrían dado con los eventos conocidos
+// hasta ese momento y las compara con las notas que los brokerages publicaron
+// sobre cada ticker dentro del horizonte siguiente.
+//
+// Los pesos de los brokerages son los actuales; si se calcularon a partir del
+// historial el resultado puede estar sesgado por información futura.
+func RunBacktest(events []models.Stock, opts BacktestOptions) (*BacktestReport, error) {
+	if opts.Strategy == nil {
+		return nil, errors.New("el backtest necesita una estrategia")
+	}
+	if opts.Horizon <= 0 {
+		return nil, errors.New("el horizonte debe ser mayor que 0")
+	}
+	from := truncateDay(opts.From)
+	to := truncateDay(opts.To)
+	if to.Before(from) {
+		return nil, errors.New("from debe ser anterior o igual a to")
+	}
+	if days := int(to.Sub(from).Hours()/24) + 1; days > MaxBacktestDays {
+		return nil, fmt.Errorf("el backtest no puede superar %d días", MaxBacktestDays)
+	}
+
+	cfg := opts.Strategy.Config()
+	useConsensus := opts.Strategy.Uses(ComponentConsensus, ComponentConsensusTarget)
+
+	parsed := make([]backtestEvent, 0, len(events))
+	for _, event := range events {
+		t, err := time.Parse(time.RFC3339Nano, event.Time)
+		if err != nil {
+			continue
+		}
+		parsed = append(parsed, backtestEvent{stock: event, time: t})
+	}
+	sort.SliceStable(parsed, func(i, j int) bool {
+		return parsed[i].time.Before(parsed[j].time)
+	})
+
+	var tickers []string
+	byTicker := make(map[string][]backtestEvent)
+	for _, event := range parsed {
+		ticker := strings.ToUpper(event.stock.Ticker)
+		if _, exists := byTicker[ticker]; !exists {
+			tickers = append(tickers, ticker)
+		}
+		byTicker[ticker] = append(byTicker[ticker], event)
+	}
+	// Recorrer los tickers en orden fijo para que los empates sean deterministas
+	sort.Strings(tickers)
+
+	report := &BacktestReport{
+		Strategy:     opts.Strategy.Name,
+		From:         from.Format(time.DateOnly),
+		To:           to.Format(time.DateOnly),
+		HorizonHours: opts.Horizon.Hours(),
+		TopN:         opts.TopN,
+		Events:       len(parsed),
+	}
+	bands := make(map[string]*BacktestBand, len(opts.Strategy.Bands))
+	for _, band := range opts.Strategy.Bands {
+		entry := &BacktestBand{Key: band.Key, Label: band.Label}
+		bands[band.Key] = entry
+		report.Bands = append(report.Bands, entry)
+	}
+
+	var allScores, allOutcomes []float64
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		now := day.AddDate(0, 0, 1)
+
+		// Eventos conocidos al final del día
+		var stocks, opinions []models.Stock
+		for _, ticker := range tickers {
+			tickerEvents := byTicker[ticker]
+			known := sort.Search(len(tickerEvents), func(i int) bool {
+				return !tickerEvents[i].time.Before(now)
+			})
+			if known == 0 {
+				continue
+			}
+			stocks = append(stocks, knownEvents(tickerEvents[:known], cfg, now)...)
+			if useConsensus {
+				opinions = append(opinions, latestPerBrokerage(tickerEvents[:known])...)
+			}
+		}
+		if len(stocks) == 0 {
+			continue
+		}
+
+		recommendations := CalculateStockRecommendationsAt(stocks, opinions, opts.Strategy, opts.Brokers, now)
+		if opts.TopN > 0 && len(recommendations) > opts.TopN {
+			recommendations = recommendations[:opts.TopN]
+		}
+
+		summary := BacktestDay{Date: day.Format(time.DateOnly), Recommendations: len(recommendations)}
+		var dayScores, dayOutcomes []float64
+		for _, recommendation := range recommendations {
+			band := bands[recommendation.RecommendationKey]
+			band.Recommendations++
+			report.Recommendations++
+
+			ticker := strings.ToUpper(recommendation.Stock.Ticker)
+			net, targetChange, evaluated := backtestOutcome(byTicker[ticker], cfg, now, now.Add(opts.Horizon))
+			if !evaluated {
+				continue
+			}
+			band.Evaluated++
+			report.Evaluated++
+			summary.Evaluated++
+			switch {
+			case net > 0:
+				band.Positive++
+			case net < 0:
+				band.Negative++
+			}
+			if targetChange != nil {
+				band.AvgTargetChangePct += *targetChange
+				band.targetChanges++
+			}
+			dayScores = append(dayScores, recommendation.Score)
+			dayOutcomes = append(dayOutcomes, float64(net))
+		}
+		summary.Correlation = correlation(dayScores, dayOutcomes)
+		report.Days = append(report.Days, summary)
+		allScores = append(allScores, dayScores...)
+		allOutcomes = append(allOutcomes, dayOutcomes...)
+	}
+
+	for _, band := range report.Bands {
+		if band.Evaluated > 0 {
+			band.PositiveRate = float64(band.Positive) / float64(band.Evaluated)
+			band.NegativeRate = float64(band.Negative) / float64(band.Evaluated)
+		}
+		if band.targetChanges > 0 {
+			band.AvgTargetChangePct /= float64(band.targetChanges)
+		}
+	}
+	report.Correlation = correlation(allScores, allOutcomes)
+	return report, nil
+}
+
+// DefaultBacktestRange completa el rango de un backtest cuando from o to son
+// cero. Por defecto to es el día un horizonte antes del último evento, para
+// que todas las recomendaciones tengan un horizonte completo que evaluar, y
+// from es 90 días antes de to.
+func DefaultBacktestRange(events []models.Stock, horizon time.Duration, from, to time.Time) (time.Time, time.Time) {
+	if to.IsZero() {
+		to = lastEventDay(events, horizon)
+	}
+	if from.IsZero() {
+		from = to.AddDate(0, 0, -89)
+	}
+	return from, to
+}
+
+// lastEventDay retorna el día un horizonte antes del último evento
+func lastEventDay(events []models.Stock, horizon time.Duration) time.Time {
+	var last string
+	for _, event := range events {
+		if event.Time > last {
+			last = event.Time
+		}
+	}
+	to := time.Now().UTC()
+	if t, err := time.Parse(time.RFC3339Nano, last); err == nil {
+		to = t
+	}
+	return truncateDay(to.Add(-horizon))
+}
+
+// truncateDay retorna el inicio del día en UTC
+func truncateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// knownEvents retorna los eventos de un ticker que se puntúan en now: el más
+// reciente o, si la configuración agrega eventos, los de la ventana
+func knownEvents(known []backtestEvent, cfg *ScoringConfig, now time.Time) []models.Stock {
+	latest := known[len(known)-1]
+	if !cfg.Decay.Aggregate {
+		return []models.Stock{latest.stock}
+	}
+
+	since := now.Add(-cfg.Decay.Window())
+	stocks := []models.Stock{latest.stock}
+	for i := len(known) - 2; i >= 0 && !known[i].time.Before(since); i-- {
+		stocks = append(stocks, known[i].stock)
+	}
+	return stocks
+}
+
+// latestPerBrokerage retorna la nota más reciente de cada brokerage
+func latestPerBrokerage(known []backtestEvent) []models.Stock {
+	latest := make(map[string]models.Stock)
+	for _, event := range known {
+		latest[strings.ToLower(event.stock.Brokerage)] = event.stock
+	}
+	opinions := make([]models.Stock, 0, len(latest))
+	for _, opinion := range latest {
+		opinions = append(opinions, opinion)
+	}
+	return opinions
+}
+
+// backtestOutcome mide las notas de un ticker en [start, end): el balance de
+// notas positivas menos negativas y el cambio porcentual del precio objetivo
+// respecto al último conocido antes de start
+func backtestOutcome(tickerEvents []backtestEvent, cfg *ScoringConfig, start, end time.Time) (net int, targetChange *float64, evaluated bool) {
+	first := sort.Search(len(tickerEvents), func(i int) bool {
+		return !tickerEvents[i].time.Before(start)
+	})
+
+	var reference float64
+	for i := first - 1; i >= 0; i-- {
+		if tickerEvents[i].stock.TargetTo > 0 {
+			reference = tickerEvents[i].stock.TargetTo
+			break
+		}
+	}
+
+	var final float64
+	for _, event := range tickerEvents[first:] {
+		if !event.time.Before(end) {
+			break
+		}
+		evaluated = true
+		switch {
+		case isPositiveEvent(cfg, event.stock):
+			net++
+		case isNegativeEvent(cfg, event.stock):
+			net--
+		}
+		if event.stock.TargetTo > 0 {
+			final = event.stock.TargetTo
+		}
+	}
+
+	if pct, ok := ChangePct(reference, final); ok && final > 0 {
+		targetChange = &pct
+	}
+	return net, targetChange, evaluated
+}
+
+// isNegativeEvent indica si un evento baja la calificación o el precio objetivo
+func isNegativeEvent(cfg *ScoringConfig, event models.Stock) bool {
+	if ratingDirection(cfg, event) < 0 {
+		return true
+	}
+	return event.TargetTo > 0 && event.TargetTo < event.TargetFrom
+}
+
+// correlation calcula el coeficiente de correlación de Pearson; retorna 0 si
+// no hay datos suficientes o alguna serie es constante
+func correlation(x, y []float64) float64 {
+	n := len(x)
+	if n < 2 || len(y) != n {
+		return 0
+	}
+
+	var meanX, meanY float64
+	for i := range x {
+		meanX += x[i]
+		meanY += y[i]
+	}
+	meanX /= float64(n)
+	meanY /= float64(n)
+
+	var cov, varX, varY float64
+	for i := range x {
+		dx, dy := x[i]-meanX, y[i]-meanY
+		cov += dx * dy
+		varX += dx * dx
+		varY += dy * dy
+	}
+	if varX == 0 || varY == 0 {
+		return 0
+	}
+	return cov / math.Sqrt(varX*varY)
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"Backend/models"
+)
+
+// DefaultBrokerWeights son los pesos que se siembran cuando la tabla de pesos
+// de brokerages está vacía
+func DefaultBrokerWeights() map[string]float64 {
+	return map[string]float64{
+		"The Goldman Sachs Group": 2.0,
+		"JPMorgan Chase":          1.5,
+		"Bank of America":         1.0,
+	}
+}
+
+// NewBrokerScorerFromWeights crea un BrokerScorer a partir de los pesos guardados
+func NewBrokerScorerFromWeights(weights []models.BrokerWeight) *DefaultBrokerScorer {
+	topBrokers := make(map[string]float64, len(weights))
+	for _, weight := range weights {
+		topBrokers[weight.Brokerage] = weight.Weight
+	}
+	return NewDefaultBrokerScorer(topBrokers)
+}
+
+// HitRateOptions ajusta el cálculo de pesos a partir del historial
+type HitRateOptions struct {
+	// Horizon es el plazo tras una nota positiva en el que se busca confirmación
+	Horizon time.Duration
+	// MinSamples es el mínimo de notas evaluables para asignar un peso
+	MinSamples int
+	// MaxWeight es el peso de un brokerage con un acierto del 100%
+	MaxWeight float64
+}
+
+// BrokerHitRate es el acierto histórico de un brokerage
+type BrokerHitRate struct {
+	Brokerage string  `json:"brokerage"`
+	Hits      int     `json:"hits"`
+	Samples   int     `json:"samples"`
+	HitRate   float64 `json:"hit_rate"`
+	Weight    float64 `json:"weight"`
+}
+
+// isPositiveEvent indica si un evento sube la calificación o el precio objetivo
+func isPositiveEvent(cfg *ScoringConfig, event models.Stock) bool {
+	if ratingDirection(cfg, event) > 0 {
+		return true
+	}
+	return event.TargetFrom > 0 && event.TargetTo > event.TargetFrom
+}
+
+// ComputeBrokerHitRates calcula el acierto de cada brokerage: de sus notas
+// positivas (subida de calificación o de precio objetivo), la proporción que
+// otro brokerage confirmó con otra nota positiva sobre el mismo ticker dentro
+// de Horizon. Las notas sin ninguna nota posterior de otro brokerage dentro del
+// plazo no se pueden evaluar y no cuentan. Solo se retornan los brokerages con
+// al menos MinSamples notas evaluables.
+func ComputeBrokerHitRates(events []models.Stock, cfg *ScoringConfig, opts HitRateOptions) []BrokerHitRate {
+	type parsedEvent struct {
+		stock models.Stock
+		time  time.Time
+	}
+
+	byTicker := make(map[string][]parsedEvent)
+	for _, event := range events {
+		t, err := time.Parse(time.RFC3339Nano, event.Time)
+		if err != nil {
+			continue
+		}
+		ticker := strings.ToUpper(event.Ticker)
+		byTicker[ticker] = append(byTicker[ticker], parsedEvent{stock: event, time: t})
+	}
+
+	hits := make(map[string]int)
+	samples := make(map[string]int)
+	for _, tickerEvents := range byTicker {
+		sort.SliceStable(tickerEvents, func(i, j int) bool {
+			return tickerEvents[i].time.Before(tickerEvents[j].time)
+		})
+
+		for i, event := range tickerEvents {
+			if !isPositiveEvent(cfg, event.stock) {
+				continue
+			}
+
+			deadline := event.time.Add(opts.Horizon)
+			evaluable, confirmed := false, false
+			for _, later := range tickerEvents[i+1:] {
+				if later.time.After(deadline) {
+					break
+				}
+				if strings.EqualFold(later.stock.Brokerage, event.stock.Brokerage) {
+					continue
+				}
+				evaluable = true
+				if isPositiveEvent(cfg, later.stock) {
+					confirmed = true
+					break
+				}
+			}
+			if !evaluable {
+				continue
+			}
+			samples[event.stock.Brokerage]++
+			if confirmed {
+				hits[event.stock.Brokerage]++
+			}
+		}
+	}
+
+	rates := make([]BrokerHitRate, 0, len(samples))
+	for brokerage, count := range samples {
+		if count < opts.MinSamples {
+			continue
+		}
+		rate := float64(hits[brokerage]) / float64(count)
+		rates = append(rates, BrokerHitRate{
+			Brokerage: brokerage,
+			Hits:      hits[brokerage],
+			Samples:   count,
+			HitRate:   rate,
+			Weight:    math.Round(rate*opts.MaxWeight*100) / 100,
+		})
+	}
+	sort.Slice(rates, func(i, j int) bool {
+		if rates[i].Weight != rates[j].Weight {
+			return rates[i].Weight > rates[j].Weight
+		}
+		return rates[i].Brokerage < rates[j].Brokerage
+	})
+	return rates
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"Backend/models"
+)
+
+// BuildConsensus resume las opiniones de los brokerages sobre un ticker.
+// opinions debe contener la nota más reciente de cada brokerage; si hay varias
+// del mismo brokerage se usa la de fecha más reciente.
+func BuildConsensus(ticker string, opinions []models.Stock, cfg *ScoringConfig) models.StockConsensus {
+	latest := make(map[string]models.Stock, len(opinions))
+	for _, opinion := range opinions {
+		key := strings.ToLower(opinion.Brokerage)
+		if current, exists := latest[key]; !exists || opinion.Time > current.Time {
+			latest[key] = opinion
+		}
+	}
+
+	consensus := models.StockConsensus{
+		Ticker:     strings.ToUpper(ticker),
+		Brokerages: len(latest),
+	}
+	var targets, previous []float64
+	for _, opinion := range latest {
+		switch ratingDirection(cfg, opinion) {
+		case 1:
+			consensus.Upgrades++
+		case -1:
+			consensus.Downgrades++
+		default:
+			consensus.Maintained++
+		}
+		if opinion.TargetTo > 0 {
+			targets = append(targets, opinion.TargetTo)
+		}
+		if opinion.TargetFrom > 0 {
+			previous = append(previous, opinion.TargetFrom)
+		}
+		if opinion.Time > consensus.LastUpdate {
+			consensus.LastUpdate = opinion.Time
+		}
+	}
+
+	consensus.Targets = len(targets)
+	if len(targets) > 0 {
+		sort.Float64s(targets)
+		var sum float64
+		for _, target := range targets {
+			sum += target
+		}
+		mean := sum / float64(len(targets))
+
+		var variance float64
+		for _, target := range targets {
+			variance += (target - mean) * (target - mean)
+		}
+		variance /= float64(len(targets))
+
+		consensus.MeanTarget = mean
+		consensus.MedianTarget = median(targets)
+		consensus.MinTarget = targets[0]
+		consensus.MaxTarget = targets[len(targets)-1]
+		consensus.TargetStdDev = math.Sqrt(variance)
+		consensus.TargetDispersion = consensus.TargetStdDev / mean
+	}
+	if len(previous) > 0 {
+		sort.Float64s(previous)
+		consensus.MedianPreviousTarget = median(previous)
+	}
+	return consensus
+}
+
+// BuildConsensusByTicker agrupa las opiniones por ticker y resume cada grupo
+func BuildConsensusByTicker(opinions []models.Stock, cfg *ScoringConfig) map[string]*models.StockConsensus {
+	groups := make(map[string][]models.Stock)
+	for _, opinion := range opinions {
+		ticker := strings.ToUpper(opinion.Ticker)
+		groups[ticker] = append(groups[ticker], opinion)
+	}
+
+	result := make(map[string]*models.StockConsensus, len(groups))
+	for ticker, group := range groups {
+		consensus := BuildConsensus(ticker, group, cfg)
+		result[ticker] = &consensus
+	}
+	return result
+}
+
+// median retorna la mediana de valores ya ordenados
+func median(sorted []float64) float64 {
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
@@ -0,0 +1,219 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"Backend/config"
+	"Backend/models"
+	"Backend/repositories"
+
+	"gorm.io/gorm"
+)
+
+// ingestionLockKey identifica el advisory lock compartido por todas las réplicas
+const ingestionLockKey int64 = 7324001
+
+// ErrIngestionInProgress indica que ya hay una ingesta en curso
+var ErrIngestionInProgress = errors.New("ya hay una ingesta en curso")
+
+// IngestionCoordinator garantiza que solo se ejecute una ingesta a la vez en el
+// proceso y, si se habilita el advisory lock, entre todas las réplicas.
+type IngestionCoordinator struct {
+	ctx           context.Context
+	db            *gorm.DB
+	advisoryLock  bool
+	staleJobAfter time.Duration
+
+	mu      sync.Mutex
+	current *models.IngestionJob
+	done    chan struct{}
+	wg      sync.WaitGroup
+	hooks   []IngestionHook
+}
+
+// IngestionHook se ejecuta al terminar con éxito una ingesta, antes de liberar
+// el lock para que otra ingesta no modifique los datos mientras tanto
+type IngestionHook func(ctx context.Context, job *models.IngestionJob) error
+
+// NewIngestionCoordinator crea un coordinador. Las ingestas que inicia se
+// ejecutan con ctx, de modo que se interrumpen al cancelarlo. Sin advisory
+// lock, los jobs en running sin progreso durante staleJobAfter se consideran
+// interrumpidos.
+func NewIngestionCoordinator(ctx context.Context, db *gorm.DB, advisoryLock bool, staleJobAfter time.Duration) *IngestionCoordinator {
+	return &IngestionCoordinator{
+		ctx:           ctx,
+		db:            db,
+		advisoryLock:  advisoryLock,
+		staleJobAfter: staleJobAfter,
+	}
+}
+
+// AfterIngestion registra un hook que se ejecuta tras cada ingesta exitosa.
+// Los errores del hook se registran en el log sin afectar al job.
+func (c *IngestionCoordinator) AfterIngestion(hook IngestionHook) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.hooks = append(c.hooks, hook)
+}
+
+// Start inicia una ingesta en segundo plano. Si ya hay una en curso retorna
+// ese job con started en false; el job puede ser nil si la ingesta la ejecuta
+// otra réplica y aún no lo ha registrado.
+func (c *IngestionCoordinator) Start(trigger string, opts repositories.IngestionOptions) (job *models.IngestionJob, started bool, err error) {
+	job, _, started, err = c.start(trigger, opts)
+	return job, started, err
+}
+
+// Run ejecuta una ingesta y espera a que termine. Retorna ErrIngestionInProgress
+// junto con el job en curso si ya había una ingesta ejecutándose.
+func (c *IngestionCoordinator) Run(ctx context.Context, trigger string, opts repositories.IngestionOptions) (*models.IngestionJob, error) {
+	job, done, started, err := c.start(trigger, opts)
+	if err != nil {
+		return nil, err
+	}
+	if !started {
+		return job, ErrIngestionInProgress
+	}
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return job, ctx.Err()
+	}
+
+	// Releer el job para obtener el resultado persistido
+	return repositories.GetIngestionJob(c.db, job.ID)
+}
+
+// Current retorna el job en curso en este proceso o nil si no hay ninguno
+func (c *IngestionCoordinator) Current() (*models.IngestionJob, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.current == nil {
+		return nil, nil
+	}
+	return repositories.GetIngestionJob(c.db, c.current.ID)
+}
+
+// Wait espera a que termine la ingesta en curso
+func (c *IngestionCoordinator) Wait() {
+	c.wg.Wait()
+}
+
+func (c *IngestionCoordinator) start(trigger string, opts repositories.IngestionOptions) (*models.IngestionJob, chan struct{}, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// El job en memoria lo modifica la goroutine de ingesta, así que a los
+	// llamadores se les entrega la versión persistida
+	if c.current != nil {
+		job, err := repositories.GetIngestionJob(c.db, c.current.ID)
+		return job, c.done, false, err
+	}
+
+	release, acquired, err := c.acquireLock()
+	if err != nil {
+		return nil, nil, false, err
+	}
+	if !acquired {
+		job, err := repositories.GetRunningIngestionJob(c.db)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, false, err
+		}
+		return job, nil, false, nil
+	}
+
+	// Con el advisory lock tomado ningún otro job puede estar en curso, así que
+	// los que siguen en running quedaron así por una caída o un reinicio. Sin
+	// él otra réplica podría estar ingiriendo, y solo se marcan los jobs que
+	// llevan más de staleJobAfter sin guardar progreso
+	var staleBefore time.Time
+	if !c.advisoryLock {
+		staleBefore = time.Now().Add(-c.staleJobAfter)
+	}
+	if reaped, err := repositories.FailStaleIngestionJobs(c.db, staleBefore); err != nil {
+		release()
+		return nil, nil, false, err
+	} else if reaped > 0 {
+		config.LogInfo(fmt.Sprintf("%d jobs de ingesta interrumpidos marcados como fallidos", reaped), "IngestionCoordinator")
+	}
+
+	job, err := repositories.NewIngestionJob(trigger, opts)
+	if err != nil {
+		release()
+		return nil, nil, false, err
+	}
+
+	snapshot := *job
+	hooks := c.hooks
+	done := make(chan struct{})
+	c.current = job
+	c.done = done
+	c.wg.Add(1)
+
+	go func() {
+		defer c.wg.Done()
+		defer release()
+
+		if err := repositories.RunIngestionJob(c.ctx, job); err != nil {
+			config.LogError(err, "IngestionCoordinator")
+		} else {
+			config.LogInfo("✅ Datos de stocks actualizados exitosamente", "IngestionCoordinator")
+			for _, hook := range hooks {
+				if err := hook(c.ctx, job); err != nil {
+					config.LogError(err, "IngestionCoordinator")
+				}
+			}
+		}
+
+		c.mu.Lock()
+		c.current = nil
+		c.done = nil
+		close(done)
+		c.mu.Unlock()
+	}()
+
+	return &snapshot, done, true, nil
+}
+
+// acquireLock toma el advisory lock en una conexión dedicada si está habilitado.
+// La función retornada libera el lock y la conexión.
+func (c *IngestionCoordinator) acquireLock() (func(), bool, error) {
+	if !c.advisoryLock {
+		return func() {}, true, nil
+	}
+
+	sqlDB, err := c.db.DB()
+	if err != nil {
+		return nil, false, fmt.Errorf("error obtaining database connection: %v", err)
+	}
+	conn, err := sqlDB.Conn(c.ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("error obtaining database connection: %v", err)
+	}
+
+	var acquired bool
+	if err := conn.QueryRowContext(c.ctx, "SELECT pg_try_advisory_lock($1)", ingestionLockKey).Scan(&acquired); err != nil {
+		conn.Close()
+		return nil, false, fmt.Errorf("error acquiring ingestion lock: %v", err)
+	}
+	if !acquired {
+		conn.Close()
+		return nil, false, nil
+	}
+
+	return func() { releaseLock(conn) }, true, nil
+}
+
+func releaseLock(conn *sql.Conn) {
+	// Se usa un contexto propio porque el del coordinador puede estar cancelado
+	if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", ingestionLockKey); err != nil {
+		config.LogError(err, "IngestionCoordinator")
+	}
+	conn.Close()
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// ErrInvalidToken indica un token mal formado, con firma inválida o vencido
+var ErrInvalidToken = errors.New("token inválido o vencido")
+
+// jwtHeader es la cabecera de los tokens emitidos; solo se acepta HS256
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// TokenClaims son los claims de un access token
+type TokenClaims struct {
+	// Subject es el ID del usuario
+	Subject   string `json:"sub"`
+	Username  string `json:"username"`
+	Role      string `json:"role"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// SignToken firma los claims con HS256
+func SignToken(claims TokenClaims, secret string) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	unsigned := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return unsigned + "." + signHS256(unsigned, secret), nil
+}
+
+// ParseToken verifica la firma HS256 y el vencimiento de un token y retorna
+// sus claims
+func ParseToken(token, secret string, now time.Time) (*TokenClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+
+	// Comparar la cabecera completa evita aceptar otros algoritmos como "none"
+	if parts[0] != jwtHeader {
+		return nil, ErrInvalidToken
+	}
+
+	expected := signHS256(parts[0]+"."+parts[1], secret)
+	if !hmac.Equal([]byte(expected), []byte(parts[2])) {
+		return nil, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	var claims TokenClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+	if claims.Subject == "" || now.Unix() >= claims.ExpiresAt {
+		return nil, ErrInvalidToken
+	}
+	return &claims, nil
+}
+
+func signHS256(unsigned, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unsigned))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"Backend/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "secreto-de-prueba"
+
+func testClaims(now time.Time) TokenClaims {
+	return TokenClaims{
+		Subject:   "1",
+		Username:  "admin",
+		Role:      models.RoleAdmin,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(15 * time.Minute).Unix(),
+	}
+}
+
+func TestParseTokenValid(t *testing.T) {
+	now := time.Now()
+	token, err := SignToken(testClaims(now), testSecret)
+	require.NoError(t, err)
+
+	claims, err := ParseToken(token, testSecret, now)
+	require.NoError(t, err)
+	assert.Equal(t, testClaims(now), *claims)
+}
+
+func TestParseTokenBadSignature(t *testing.T) {
+	now := time.Now()
+	token, err := SignToken(testClaims(now), testSecret)
+	require.NoError(t, err)
+
+	_, err = ParseToken(token, "otro-secreto", now)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	// Cambiar los claims invalida la firma
+	parts := strings.Split(token, ".")
+	forged := testClaims(now)
+	forged.Role = "superadmin"
+	payload, err := json.Marshal(forged)
+	require.NoError(t, err)
+	parts[1] = base64.RawURLEncoding.EncodeToString(payload)
+	_, err = ParseToken(strings.Join(parts, "."), testSecret, now)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestParseTokenWrongAlgorithm(t *testing.T) {
+	now := time.Now()
+	payload, err := json.Marshal(testClaims(now))
+	require.NoError(t, err)
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+
+	headers := []string{
+		`{"alg":"none","typ":"JWT"}`,
+		`{"alg":"HS512","typ":"JWT"}`,
+		`{"alg":"RS256","typ":"JWT"}`,
+	}
+	for _, header := range headers {
+		unsigned := base64.RawURLEncoding.EncodeToString([]byte(header)) + "." + encoded
+		for _, signature := range []string{"", signHS256(unsigned, testSecret)} {
+			_, err := ParseToken(unsigned+"."+signature, testSecret, now)
+			assert.ErrorIs(t, err, ErrInvalidToken, "cabecera %s", header)
+		}
+	}
+}
+
+func TestParseTokenExpired(t *testing.T) {
+	now := time.Now()
+	token, err := SignToken(testClaims(now), testSecret)
+	require.NoError(t, err)
+
+	_, err = ParseToken(token, testSecret, now.Add(15*time.Minute))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	_, err = ParseToken(token, testSecret, now.Add(15*time.Minute-time.Second))
+	assert.NoError(t, err)
+}
+
+func TestParseTokenMalformed(t *testing.T) {
+	for _, token := range []string{"", "a.b", "a.b.c.d", jwtHeader + ".!!!.x"} {
+		_, err := ParseToken(token, testSecret, time.Now())
+		assert.ErrorIs(t, err, ErrInvalidToken, "token %q", token)
+	}
+}
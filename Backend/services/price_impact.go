@@ -0,0 +1,100 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// PriceBand es un tramo de cambio porcentual del precio objetivo. Un cambio
+// pertenece al tramo con el mayor MinPct que no lo supere.
+type PriceBand struct {
+	MinPct float64 `json:"min_pct" yaml:"min_pct"`
+	Score  float64 `json:"score" yaml:"score"`
+}
+
+// PriceConfig define cómo se puntúa el cambio del precio objetivo. El cambio se
+// mide en porcentaje sobre TargetFrom para no favorecer a las acciones caras.
+// Los cambios menores al primer tramo usan el puntaje del primer tramo.
+type PriceConfig struct {
+	Bands []PriceBand `json:"bands" yaml:"bands"`
+	// MissingFromScore es el puntaje cuando no hay precio objetivo anterior
+	// (TargetFrom vacío o cero) pero sí uno nuevo, como en un inicio de cobertura
+	MissingFromScore float64 `json:"missing_from_score" yaml:"missing_from_score"`
+}
+
+// defaultPriceConfig retorna los tramos por defecto: recortes puntúan negativo,
+// cambios de ±2% son neutros y las subidas puntúan más cuanto mayores son
+func defaultPriceConfig() PriceConfig {
+	return PriceConfig{
+		Bands: []PriceBand{
+			{MinPct: -100, Score: -3},
+			{MinPct: -20, Score: -2},
+			{MinPct: -10, Score: -1},
+			{MinPct: -2, Score: 0},
+			{MinPct: 2, Score: 2},
+			{MinPct: 10, Score: 3},
+			{MinPct: 20, Score: 4},
+			{MinPct: 40, Score: 5},
+		},
+		MissingFromScore: 0,
+	}
+}
+
+// prepare valida los tramos y los ordena por MinPct
+func (p *PriceConfig) prepare() error {
+	if len(p.Bands) == 0 {
+		return fmt.Errorf("price.bands no puede estar vacío")
+	}
+	sort.SliceStable(p.Bands, func(i, j int) bool {
+		return p.Bands[i].MinPct < p.Bands[j].MinPct
+	})
+	for i, band := range p.Bands {
+		if math.IsNaN(band.MinPct) || math.IsInf(band.MinPct, 0) {
+			return fmt.Errorf("min_pct inválido en price.bands")
+		}
+		if math.IsNaN(band.Score) || math.IsInf(band.Score, 0) {
+			return fmt.Errorf("puntaje inválido en el tramo min_pct=%g de price.bands", band.MinPct)
+		}
+		if i > 0 && band.MinPct == p.Bands[i-1].MinPct {
+			return fmt.Errorf("min_pct=%g repetido en price.bands", band.MinPct)
+		}
+	}
+	if math.IsNaN(p.MissingFromScore) || math.IsInf(p.MissingFromScore, 0) {
+		return fmt.Errorf("price.missing_from_score inválido")
+	}
+	return nil
+}
+
+// ChangePct retorna el cambio porcentual del precio objetivo, o false si no
+// hay precio objetivo anterior con el que comparar
+func ChangePct(targetFrom, targetTo float64) (float64, bool) {
+	if targetFrom <= 0 {
+		return 0, false
+	}
+	return (targetTo - targetFrom) / targetFrom * 100, true
+}
+
+// BandFor retorna el tramo al que pertenece un cambio porcentual
+func (p *PriceConfig) BandFor(pct float64) PriceBand {
+	band := p.Bands[0]
+	for _, candidate := range p.Bands[1:] {
+		if pct < candidate.MinPct {
+			break
+		}
+		band = candidate
+	}
+	return band
+}
+
+// Impact puntúa el cambio entre dos precios objetivo
+func (p *PriceConfig) Impact(targetFrom, targetTo float64) float64 {
+	if targetTo <= 0 {
+		return 0
+	}
+	pct, ok := ChangePct(targetFrom, targetTo)
+	if !ok {
+		return p.MissingFromScore
+	}
+	return p.BandFor(pct).Score
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// RatingTier es un nivel canónico de calificación
+type RatingTier string
+
+const (
+	TierStrongSell RatingTier = "strong_sell"
+	TierSell       RatingTier = "sell"
+	TierHold       RatingTier = "hold"
+	TierBuy        RatingTier = "buy"
+	TierStrongBuy  RatingTier = "strong_buy"
+	// TierNone se usa cuando el evento no tiene calificación anterior (inicio de cobertura)
+	TierNone RatingTier = "none"
+	// TierUnknown se usa para etiquetas que no están en la taxonomía
+	TierUnknown RatingTier = "unknown"
+)
+
+// RatingTiers son los niveles canónicos ordenados de menor a mayor
+var RatingTiers = []RatingTier{TierStrongSell, TierSell, TierHold, TierBuy, TierStrongBuy}
+
+func isRatingTier(tier RatingTier) bool {
+	for _, known := range RatingTiers {
+		if tier == known {
+			return true
+		}
+	}
+	return false
+}
+
+// RatingConfig define la taxonomía de calificaciones y la matriz de transiciones.
+// Aliases asigna a cada nivel las etiquetas de los brokerages que lo representan;
+// Matrix asigna el puntaje de pasar de un nivel (o de "none") a otro. Las
+// transiciones que no aparecen en la matriz puntúan 0.
+type RatingConfig struct {
+	Aliases map[RatingTier][]string               `json:"aliases" yaml:"aliases"`
+	Matrix  map[RatingTier]map[RatingTier]float64 `json:"matrix" yaml:"matrix"`
+
+	// labels es el índice normalizado etiqueta -> nivel
+	labels map[string]RatingTier
+}
+
+// defaultRatingConfig retorna la taxonomía y la matriz por defecto
+func defaultRatingConfig() RatingConfig {
+	return RatingConfig{
+		Aliases: map[RatingTier][]string{
+			TierStrongBuy: {"Strong-Buy", "Strong Buy", "Top Pick", "Conviction Buy"},
+			TierBuy: {"Buy", "Outperform", "Overweight", "Market Outperform", "Sector Outperform",
+				"Outperformer", "Positive", "Accumulate", "Add", "Moderate Buy", "Speculative Buy"},
+			TierHold: {"Hold", "Neutral", "Market Perform", "Sector Perform", "Equal Weight", "In-Line",
+				"Peer Perform", "Sector Weight", "Market Weight", "Perform", "Mixed"},
+			TierSell: {"Sell", "Underperform", "Underweight", "Market Underperform",
+				"Sector Underperform", "Reduce", "Negative", "Moderate Sell"},
+			TierStrongSell: {"Strong Sell", "Strong-Sell"},
+		},
+		Matrix: map[RatingTier]map[RatingTier]float64{
+			TierNone: {
+				TierStrongBuy: 2.5, TierBuy: 2, TierHold: 0, TierSell: -1, TierStrongSell: -1.5,
+			},
+			TierStrongSell: {
+				TierStrongBuy: 3.5, TierBuy: 3, TierHold: 1.5, TierSell: 0.5, TierStrongSell: -1.5,
+			},
+			TierSell: {
+				TierStrongBuy: 3.5, TierBuy: 3, TierHold: 1, TierSell: -1, TierStrongSell: -1.5,
+			},
+			TierHold: {
+				TierStrongBuy: 3, TierBuy: 2, TierHold: 0, TierSell: -1.5, TierStrongSell: -2,
+			},
+			TierBuy: {
+				TierStrongBuy: 1.5, TierBuy: 1, TierHold: -1, TierSell: -2, TierStrongSell: -2.5,
+			},
+			TierStrongBuy: {
+				TierStrongBuy: 1.5, TierBuy: -0.5, TierHold: -1.5, TierSell: -2.5, TierStrongSell: -3,
+			},
+		},
+	}
+}
+
+// normalizeRatingLabel unifica mayúsculas, guiones y espacios de una etiqueta
+func normalizeRatingLabel(label string) string {
+	label = strings.ToLower(label)
+	label = strings.NewReplacer("-", " ", "_", " ").Replace(label)
+	return strings.Join(strings.Fields(label), " ")
+}
+
+// prepare valida la configuración y construye el índice de etiquetas
+func (r *RatingConfig) prepare() error {
+	r.labels = make(map[string]RatingTier)
+	for tier, aliases := range r.Aliases {
+		if !isRatingTier(tier) {
+			return fmt.Errorf("nivel de calificación desconocido %q en aliases", tier)
+		}
+		for _, alias := range aliases {
+			key := normalizeRatingLabel(alias)
+			if previous, exists := r.labels[key]; exists && previous != tier {
+				return fmt.Errorf("la etiqueta %q está asignada a %q y %q", alias, previous, tier)
+			}
+			r.labels[key] = tier
+		}
+	}
+
+	for from, row := range r.Matrix {
+		if from != TierNone && !isRatingTier(from) {
+			return fmt.Errorf("nivel de calificación desconocido %q en matrix", from)
+		}
+		for to, score := range row {
+			if !isRatingTier(to) {
+				return fmt.Errorf("nivel de calificación desconocido %q en matrix.%s", to, from)
+			}
+			if math.IsNaN(score) || math.IsInf(score, 0) {
+				return fmt.Errorf("puntaje inválido en matrix.%s.%s", from, to)
+			}
+		}
+	}
+	return nil
+}
+
+// Normalize convierte una etiqueta de un brokerage en su nivel canónico.
+// Retorna TierNone para etiquetas vacías y TierUnknown si no está en la taxonomía.
+func (r *RatingConfig) Normalize(label string) RatingTier {
+	key := normalizeRatingLabel(label)
+	if key == "" {
+		return TierNone
+	}
+	if tier, exists := r.labels[key]; exists {
+		return tier
+	}
+	return TierUnknown
+}
+
+// TransitionScore retorna el puntaje de una transición de calificación
+func (r *RatingConfig) TransitionScore(ratingFrom, ratingTo string) float64 {
+	from, to := r.Normalize(ratingFrom), r.Normalize(ratingTo)
+	if from == TierUnknown || to == TierUnknown || to == TierNone {
+		return 0
+	}
+	return r.Matrix[from][to]
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"slices"
+
+	"Backend/models"
+)
+
+// Permisos que exigen las rutas protegidas
+const (
+	// PermissionViewStocks permite consultar acciones y recomendaciones
+	PermissionViewStocks = "stocks:view"
+	// PermissionRunBacktest permite ejecutar backtests
+	PermissionRunBacktest = "scoring:backtest"
+	// PermissionReloadScoring permite recargar la configuración de puntajes
+	PermissionReloadScoring = "scoring:reload"
+	// PermissionViewIngestion permite consultar los jobs y el estado de la ingesta
+	PermissionViewIngestion = "ingestion:view"
+	// PermissionRunIngestion permite iniciar ingestas
+	PermissionRunIngestion = "ingestion:run"
+	// PermissionViewBrokerWeights permite consultar los pesos de los brokerages
+	PermissionViewBrokerWeights = "broker_weights:view"
+	// PermissionEditBrokerWeights permite editar y calcular los pesos de los brokerages
+	PermissionEditBrokerWeights = "broker_weights:edit"
+	// PermissionTakeSnapshots permite guardar snapshots de recomendaciones
+	PermissionTakeSnapshots = "snapshots:take"
+	// PermissionManageUsers permite crear, editar y eliminar usuarios
+	PermissionManageUsers = "users:manage"
+	// PermissionManageAPIKeys permite crear, listar y revocar API keys
+	PermissionManageAPIKeys = "api_keys:manage"
+)
+
+// Roles lista los roles válidos, de menor a mayor acceso
+var Roles = []string{models.RoleViewer, models.RoleAnalyst, models.RoleAdmin}
+
+// rolePermissions son los permisos propios de cada rol; cada rol incluye
+// además los de los roles anteriores en Roles
+var rolePermissions = map[string][]string{
+	models.RoleViewer: {
+		PermissionViewStocks,
+	},
+	models.RoleAnalyst: {
+		PermissionRunBacktest,
+		PermissionViewIngestion,
+		PermissionViewBrokerWeights,
+	},
+	models.RoleAdmin: {
+		PermissionReloadScoring,
+		PermissionRunIngestion,
+		PermissionEditBrokerWeights,
+		PermissionTakeSnapshots,
+		PermissionManageUsers,
+		PermissionManageAPIKeys,
+	},
+}
+
+// IsValidRole indica si role es uno de los roles definidos
+func IsValidRole(role string) bool {
+	return slices.Contains(Roles, role)
+}
+
+// HasPermission indica si un rol tiene un permiso
+func HasPermission(role, permission string) bool {
+	return slices.Contains(RolePermissions(role), permission)
+}
+
+// RolePermissions retorna todos los permisos de un rol
+func RolePermissions(role string) []string {
+	if !IsValidRole(role) {
+		return nil
+	}
+	var permissions []string
+	for _, current := range Roles {
+		permissions = append(permissions, rolePermissions[current]...)
+		if current == role {
+			break
+		}
+	}
+	return permissions
+}
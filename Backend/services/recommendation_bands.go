@@ -0,0 +1,152 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// RecommendationBand es un tramo de puntaje con su recomendación. Un puntaje
+// pertenece al tramo si Min <= puntaje < Max; Min nil es -infinito y Max nil
+// es +infinito.
+type RecommendationBand struct {
+	// Key identifica el tramo de forma estable (p.ej. strong_buy) para que las
+	// etiquetas se puedan traducir sin cambiar los tramos
+	Key   string   `json:"key" yaml:"key"`
+	Label string   `json:"label" yaml:"label"`
+	Min   *float64 `json:"min" yaml:"min"`
+	Max   *float64 `json:"max" yaml:"max"`
+}
+
+func bandLimit(value float64) *float64 {
+	return &value
+}
+
+// defaultRecommendationBands retorna los tramos por defecto: Sell por debajo
+// de 3, Hold desde 3, Buy desde 5 y Strong Buy desde 7
+func defaultRecommendationBands() []RecommendationBand {
+	return []RecommendationBand{
+		{Key: "sell", Label: "Sell", Max: bandLimit(3)},
+		{Key: "hold", Label: "Hold", Min: bandLimit(3), Max: bandLimit(5)},
+		{Key: "buy", Label: "Buy", Min: bandLimit(5), Max: bandLimit(7)},
+		{Key: "strong_buy", Label: "Strong Buy", Min: bandLimit(7)},
+	}
+}
+
+// prepareBands ordena los tramos y valida que cubran todos los puntajes sin
+// huecos ni solapamientos
+func prepareBands(bands []RecommendationBand) ([]RecommendationBand, error) {
+	if len(bands) == 0 {
+		return nil, fmt.Errorf("debe haber al menos un tramo de recomendación")
+	}
+
+	sorted := make([]RecommendationBand, len(bands))
+	copy(sorted, bands)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Min == nil || sorted[j].Min == nil {
+			return sorted[i].Min == nil && sorted[j].Min != nil
+		}
+		return *sorted[i].Min < *sorted[j].Min
+	})
+
+	keys := make(map[string]bool, len(sorted))
+	for i, band := range sorted {
+		if band.Key == "" || band.Label == "" {
+			return nil, fmt.Errorf("cada tramo de recomendación necesita key y label")
+		}
+		if keys[band.Key] {
+			return nil, fmt.Errorf("el tramo %q está repetido", band.Key)
+		}
+		keys[band.Key] = true
+
+		for _, limit := range []*float64{band.Min, band.Max} {
+			if limit != nil && (math.IsNaN(*limit) || math.IsInf(*limit, 0)) {
+				return nil, fmt.Errorf("límite inválido en el tramo %q", band.Key)
+			}
+		}
+		if band.Min != nil && band.Max != nil && *band.Min >= *band.Max {
+			return nil, fmt.Errorf("el tramo %q tiene min mayor o igual que max", band.Key)
+		}
+
+		if i == 0 {
+			if band.Min != nil {
+				return nil, fmt.Errorf("el primer tramo (%q) no debe tener min para cubrir todos los puntajes bajos", band.Key)
+			}
+		} else {
+			previous := sorted[i-1]
+			if band.Min == nil {
+				return nil, fmt.Errorf("solo el primer tramo puede omitir min (%q)", band.Key)
+			}
+			if previous.Max == nil || *previous.Max != *band.Min {
+				return nil, fmt.Errorf("los tramos %q y %q no son contiguos: max del primero debe ser igual a min del segundo", previous.Key, band.Key)
+			}
+		}
+		if i == len(sorted)-1 && band.Max != nil {
+			return nil, fmt.Errorf("el último tramo (%q) no debe tener max para cubrir todos los puntajes altos", band.Key)
+		}
+	}
+	return sorted, nil
+}
+
+// Classify retorna el tramo al que pertenece un puntaje
+func (s *ScoringStrategy) Classify(score float64) RecommendationBand {
+	for _, band := range s.Bands {
+		if band.Max == nil || score < *band.Max {
+			return band
+		}
+	}
+	return s.Bands[len(s.Bands)-1]
+}
+
+// LocalizedBands retorna los tramos de la estrategia con las etiquetas del
+// conjunto indicado; las claves que el conjunto no define conservan su etiqueta
+func (c *ScoringConfig) LocalizedBands(strategy *ScoringStrategy, labelSet string) ([]RecommendationBand, error) {
+	bands := make([]RecommendationBand, len(strategy.Bands))
+	copy(bands, strategy.Bands)
+	if labelSet == "" {
+		return bands, nil
+	}
+
+	labels, exists := c.LabelSets[labelSet]
+	if !exists {
+		return nil, fmt.Errorf("conjunto de etiquetas desconocido %q", labelSet)
+	}
+	for i := range bands {
+		if label, exists := labels[bands[i].Key]; exists {
+			bands[i].Label = label
+		}
+	}
+	return bands, nil
+}
+
+// LabelSetNames retorna los nombres de los conjuntos de etiquetas ordenados
+func (c *ScoringConfig) LabelSetNames() []string {
+	names := make([]string, 0, len(c.LabelSets))
+	for name := range c.LabelSets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validateLabelSets verifica que cada conjunto de etiquetas solo use claves de
+// tramos existentes
+func validateLabelSets(labelSets map[string]map[string]string, strategies map[string]*ScoringStrategy) error {
+	keys := make(map[string]bool)
+	for _, strategy := range strategies {
+		for _, band := range strategy.Bands {
+			keys[band.Key] = true
+		}
+	}
+	for name, labels := range labelSets {
+		for key, label := range labels {
+			if !keys[key] {
+				return fmt.Errorf("el conjunto de etiquetas %q usa el tramo desconocido %q", name, key)
+			}
+			if label == "" {
+				return fmt.Errorf("etiqueta vacía para %q en el conjunto %q", key, name)
+			}
+		}
+	}
+	return nil
+}
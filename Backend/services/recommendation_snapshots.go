@@ -0,0 +1,142 @@
+package services
+
+import (
+	"strings"
+	"time"
+
+	"Backend/models"
+	"Backend/repositories"
+
+	"gorm.io/gorm"
+)
+
+// TakeRecommendationSnapshot calcula las recomendaciones de una estrategia y
+// las guarda como snapshot
+func TakeRecommendationSnapshot(db *gorm.DB, strategy *ScoringStrategy, jobID *int64, now time.Time) (*models.RecommendationSnapshot, error) {
+	recommendations, err := RecommendStocks(db, strategy, now)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &models.RecommendationSnapshot{
+		Strategy: strategy.Name,
+		JobID:    jobID,
+		TakenAt:  now.UTC(),
+		Items:    make([]models.RecommendationSnapshotItem, 0, len(recommendations)),
+	}
+	for i, recommendation := range recommendations {
+		snapshot.Items = append(snapshot.Items, models.RecommendationSnapshotItem{
+			Rank:              i + 1,
+			Ticker:            strings.ToUpper(recommendation.Stock.Ticker),
+			Company:           recommendation.Stock.Company,
+			Brokerage:         recommendation.Stock.Brokerage,
+			EventTime:         recommendation.Stock.Time,
+			Score:             recommendation.Score,
+			Recommendation:    recommendation.Recommendation,
+			RecommendationKey: recommendation.RecommendationKey,
+		})
+	}
+
+	if err := repositories.CreateRecommendationSnapshot(db, snapshot); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// TakeRecommendationSnapshots guarda un snapshot por cada estrategia
+// configurada; se ejecuta al terminar cada ingesta
+func TakeRecommendationSnapshots(db *gorm.DB, jobID *int64) ([]*models.RecommendationSnapshot, error) {
+	cfg := GetScoringConfig()
+	now := time.Now()
+
+	var snapshots []*models.RecommendationSnapshot
+	for _, name := range cfg.StrategyNames() {
+		strategy, _ := cfg.Strategy(name)
+		snapshot, err := TakeRecommendationSnapshot(db, strategy, jobID, now)
+		if err != nil {
+			return snapshots, err
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	return snapshots, nil
+}
+
+// PruneRecommendationSnapshots elimina los snapshots con más de retentionDays
+// días de antigüedad; con retentionDays 0 se conservan todos
+func PruneRecommendationSnapshots(db *gorm.DB, retentionDays int, now time.Time) (int64, error) {
+	if retentionDays <= 0 {
+		return 0, nil
+	}
+	return repositories.DeleteRecommendationSnapshotsBefore(db, now.AddDate(0, 0, -retentionDays))
+}
+
+// DiffRecommendationSnapshots compara dos snapshots: los tickers que entran,
+// los que salen y los que cambian de tramo. Un cambio es una mejora si el
+// tramo nuevo está por encima en bands o, si alguno de los tramos ya no
+// existe, si el puntaje subió.
+func DiffRecommendationSnapshots(from, to *models.RecommendationSnapshot, bands []RecommendationBand) models.SnapshotDiff {
+	diff := models.SnapshotDiff{
+		From:    from,
+		To:      to,
+		Entered: []models.RecommendationSnapshotItem{},
+		Exited:  []models.RecommendationSnapshotItem{},
+		Changed: []models.SnapshotChange{},
+	}
+
+	order := make(map[string]int, len(bands))
+	for i, band := range bands {
+		order[band.Key] = i
+	}
+
+	previous := make(map[string]models.RecommendationSnapshotItem, len(from.Items))
+	for _, item := range from.Items {
+		previous[item.Ticker] = item
+	}
+	current := make(map[string]bool, len(to.Items))
+
+	for _, item := range to.Items {
+		current[item.Ticker] = true
+		before, exists := previous[item.Ticker]
+		if !exists {
+			diff.Entered = append(diff.Entered, item)
+			continue
+		}
+		if before.RecommendationKey == item.RecommendationKey {
+			continue
+		}
+
+		upgrade := item.Score > before.Score
+		fromRank, fromKnown := order[before.RecommendationKey]
+		toRank, toKnown := order[item.RecommendationKey]
+		if fromKnown && toKnown {
+			upgrade = toRank > fromRank
+		}
+		diff.Changed = append(diff.Changed, models.SnapshotChange{
+			Ticker:  item.Ticker,
+			From:    before,
+			To:      item,
+			Upgrade: upgrade,
+		})
+	}
+
+	for _, item := range from.Items {
+		if !current[item.Ticker] {
+			diff.Exited = append(diff.Exited, item)
+		}
+	}
+	return diff
+}
+
+// LocalizeSnapshot cambia las etiquetas de un snapshot por las de bands según
+// la key de cada tramo
+func LocalizeSnapshot(snapshot *models.RecommendationSnapshot, bands []RecommendationBand) {
+	labels := make(map[string]string, len(bands))
+	for _, band := range bands {
+		labels[band.Key] = band.Label
+	}
+	for i := range snapshot.Items {
+		if label, exists := labels[snapshot.Items[i].RecommendationKey]; exists {
+			snapshot.Items[i].Recommendation = label
+		}
+	}
+}
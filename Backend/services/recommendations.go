@@ -0,0 +1,102 @@
+package services
+
+import (
+	"time"
+
+	"Backend/models"
+	"Backend/repositories"
+
+	"gorm.io/gorm"
+)
+
+// LoadBrokerScorer crea un BrokerScorer con los pesos de los brokerages
+// guardados en la base de datos
+func LoadBrokerScorer(db *gorm.DB) (BrokerScorer, error) {
+	weights, err := repositories.GetBrokerWeights(db)
+	if err != nil {
+		return nil, err
+	}
+	return NewBrokerScorerFromWeights(weights), nil
+}
+
+// RecommendStocks calcula las recomendaciones de todos los tickers con los
+// eventos guardados en la base de datos, ordenadas por puntaje
+func RecommendStocks(db *gorm.DB, strategy *ScoringStrategy, now time.Time) ([]models.StockRecommendation, error) {
+	stocks, err := repositories.GetAllStocks(db)
+	if err != nil {
+		return nil, err
+	}
+	if len(stocks) == 0 {
+		return []models.StockRecommendation{}, nil
+	}
+
+	stocks, err = withRecentEvents(db, strategy, stocks, now)
+	if err != nil {
+		return nil, err
+	}
+	opinions, err := brokerageOpinions(db, strategy)
+	if err != nil {
+		return nil, err
+	}
+	scorer, err := LoadBrokerScorer(db)
+	if err != nil {
+		return nil, err
+	}
+	return CalculateStockRecommendationsAt(stocks, opinions, strategy, scorer, now), nil
+}
+
+// RecommendTicker calcula la recomendación de un ticker con los eventos
+// guardados en la base de datos. Retorna gorm.ErrRecordNotFound si el ticker
+// no tiene eventos.
+func RecommendTicker(db *gorm.DB, strategy *ScoringStrategy, ticker string, now time.Time) (*models.StockRecommendation, error) {
+	stock, err := repositories.GetLatestStock(db, ticker)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := withRecentEvents(db, strategy, []models.Stock{*stock}, now, ticker)
+	if err != nil {
+		return nil, err
+	}
+	opinions, err := brokerageOpinions(db, strategy, ticker)
+	if err != nil {
+		return nil, err
+	}
+	var consensus *models.StockConsensus
+	if len(opinions) > 0 {
+		built := BuildConsensus(ticker, opinions, strategy.Config())
+		consensus = &built
+	}
+	scorer, err := LoadBrokerScorer(db)
+	if err != nil {
+		return nil, err
+	}
+
+	recommendation := ExplainTicker(events, consensus, strategy, scorer, now)
+	return &recommendation, nil
+}
+
+// withRecentEvents agrega a los eventos más recientes de cada ticker los
+// demás eventos dentro de la ventana de decaimiento cuando la configuración
+// de la estrategia combina los eventos de cada ticker
+func withRecentEvents(db *gorm.DB, strategy *ScoringStrategy, latest []models.Stock, now time.Time, tickers ...string) ([]models.Stock, error) {
+	decay := strategy.Config().Decay
+	if !decay.Aggregate {
+		return latest, nil
+	}
+	since := now.Add(-decay.Window())
+	recent, err := repositories.GetRecentStocks(db, since, tickers...)
+	if err != nil {
+		return nil, err
+	}
+	return append(latest, recent...), nil
+}
+
+// brokerageOpinions obtiene la última nota de cada brokerage por ticker cuando
+// la estrategia usa el consenso; en otro caso retorna nil
+func brokerageOpinions(db *gorm.DB, strategy *ScoringStrategy, tickers ...string) ([]models.Stock, error) {
+	if !strategy.Uses(ComponentConsensus, ComponentConsensusTarget) {
+		return nil, nil
+	}
+	return repositories.GetLatestStocksPerBrokerage(db, tickers...)
+}
@@ -0,0 +1,260 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"Backend/config"
+)
+
+// Schedule calcula la siguiente ejecución de una tarea programada
+type Schedule interface {
+	Next(after time.Time) time.Time
+}
+
+// ParseSchedule interpreta una expresión de programación.
+// Acepta "@every <duración>", "@hourly", "@daily" o cron de cinco campos
+// (minuto, hora, día del mes, mes, día de la semana).
+func ParseSchedule(expr string) (Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	switch {
+	case strings.HasPrefix(expr, "@every "):
+		interval, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(expr, "@every ")))
+		if err != nil {
+			return nil, fmt.Errorf("intervalo inválido en %q: %v", expr, err)
+		}
+		if interval < time.Minute {
+			return nil, fmt.Errorf("el intervalo mínimo es de un minuto: %q", expr)
+		}
+		return IntervalSchedule{Interval: interval}, nil
+	case expr == "@hourly":
+		return ParseCron("0 * * * *")
+	case expr == "@daily":
+		return ParseCron("0 0 * * *")
+	default:
+		return ParseCron(expr)
+	}
+}
+
+// IntervalSchedule ejecuta una tarea a intervalos fijos
+type IntervalSchedule struct {
+	Interval time.Duration
+}
+
+// Next implementa Schedule
+func (s IntervalSchedule) Next(after time.Time) time.Time {
+	return after.Add(s.Interval)
+}
+
+// CronSchedule ejecuta una tarea según una expresión cron de cinco campos
+type CronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	domAny, dowAny                bool
+}
+
+// ParseCron interpreta una expresión cron de cinco campos con soporte para
+// "*", listas ("1,15"), rangos ("1-5") y pasos ("*/10")
+func ParseCron(expr string) (*CronSchedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("la expresión cron debe tener 5 campos: %q", expr)
+	}
+
+	var s CronSchedule
+	var err error
+	if s.minute, err = parseCronField(fields[0], 0, 59); err != nil {
+		return nil, err
+	}
+	if s.hour, err = parseCronField(fields[1], 0, 23); err != nil {
+		return nil, err
+	}
+	if s.dom, err = parseCronField(fields[2], 1, 31); err != nil {
+		return nil, err
+	}
+	if s.month, err = parseCronField(fields[3], 1, 12); err != nil {
+		return nil, err
+	}
+	if s.dow, err = parseCronField(fields[4], 0, 7); err != nil {
+		return nil, err
+	}
+	// El domingo puede expresarse como 0 o 7
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.domAny = fields[2] == "*"
+	s.dowAny = fields[4] == "*"
+	return &s, nil
+}
+
+func parseCronField(field string, min, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if idx := strings.Index(part, "/"); idx >= 0 {
+			value, err := strconv.Atoi(part[idx+1:])
+			if err != nil || value <= 0 {
+				return 0, fmt.Errorf("paso inválido en el campo cron %q", field)
+			}
+			step = value
+			part = part[:idx]
+		}
+
+		start, end := min, max
+		if part != "*" {
+			bounds := strings.SplitN(part, "-", 2)
+			value, err := strconv.Atoi(bounds[0])
+			if err != nil {
+				return 0, fmt.Errorf("valor inválido en el campo cron %q", field)
+			}
+			start, end = value, value
+			if len(bounds) == 2 {
+				if end, err = strconv.Atoi(bounds[1]); err != nil {
+					return 0, fmt.Errorf("valor inválido en el campo cron %q", field)
+				}
+			} else if step > 1 {
+				end = max
+			}
+		}
+		if start < min || end > max || start > end {
+			return 0, fmt.Errorf("valor fuera de rango en el campo cron %q", field)
+		}
+
+		for i := start; i <= end; i += step {
+			bits |= 1 << uint(i)
+		}
+	}
+	return bits, nil
+}
+
+// Next implementa Schedule. Busca el siguiente minuto que cumpla la expresión,
+// con un límite de cinco años para expresiones imposibles (p.ej. 31 de febrero).
+func (s *CronSchedule) Next(after time.Time) time.Time {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// dayMatches aplica la semántica cron: si ambos campos de día están
+// restringidos basta con que coincida uno de ellos
+func (s *CronSchedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domAny || s.dowAny {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// JobFunc es la tarea ejecutada por el Scheduler
+type JobFunc func(ctx context.Context) error
+
+// Scheduler ejecuta una tarea periódicamente en segundo plano.
+// Si una ejecución sigue en curso cuando toca la siguiente, ésta se omite.
+type Scheduler struct {
+	name     string
+	schedule Schedule
+	jitter   time.Duration
+	job      JobFunc
+
+	running atomic.Bool
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// NewScheduler crea un Scheduler. El jitter añade un retraso aleatorio
+// en [0, jitter) a cada ejecución para no sincronizar réplicas.
+func NewScheduler(name string, schedule Schedule, jitter time.Duration, job JobFunc) *Scheduler {
+	return &Scheduler{
+		name:     name,
+		schedule: schedule,
+		jitter:   jitter,
+		job:      job,
+	}
+}
+
+// Start inicia el ciclo del scheduler hasta que se cancele ctx o se llame a Stop.
+// Si runOnStart es true la tarea se ejecuta inmediatamente.
+func (s *Scheduler) Start(ctx context.Context, runOnStart bool) {
+	ctx, s.cancel = context.WithCancel(ctx)
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		if runOnStart {
+			s.TryRun(ctx)
+		}
+
+		for {
+			next := s.schedule.Next(time.Now())
+			if next.IsZero() {
+				config.LogInfo("Sin próximas ejecuciones programadas", s.name)
+				return
+			}
+			if s.jitter > 0 {
+				next = next.Add(rand.N(s.jitter))
+			}
+
+			timer := time.NewTimer(time.Until(next))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+				s.TryRun(ctx)
+			}
+		}
+	}()
+}
+
+// TryRun ejecuta la tarea en la goroutine actual salvo que ya haya una
+// ejecución en curso. Retorna false si la ejecución se omitió.
+func (s *Scheduler) TryRun(ctx context.Context) bool {
+	if !s.running.CompareAndSwap(false, true) {
+		config.LogInfo("Ejecución anterior en curso, se omite esta ejecución", s.name)
+		return false
+	}
+	defer s.running.Store(false)
+
+	start := time.Now()
+	if err := s.job(ctx); err != nil {
+		config.LogError(err, s.name)
+	} else {
+		config.LogInfo(fmt.Sprintf("Ejecución completada en %s", time.Since(start).Round(time.Millisecond)), s.name)
+	}
+	return true
+}
+
+// Stop cancela el scheduler y espera a que termine la ejecución en curso
+func (s *Scheduler) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+}
@@ -0,0 +1,378 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"Backend/models"
+)
+
+// ScoreInput contiene los datos disponibles para puntuar un ticker
+type ScoreInput struct {
+	// Stock es el evento que se puntúa, normalmente el más reciente del ticker
+	Stock models.Stock
+	// Events son otros eventos recientes del ticker; puede estar vacío
+	Events []models.Stock
+	// Consensus resume la última nota de cada brokerage sobre el ticker; si es
+	// nil se calcula a partir de Events
+	Consensus *models.StockConsensus
+	Brokers   BrokerScorer
+	Config    *ScoringConfig
+	Now       time.Time
+}
+
+// ComponentResult es el puntaje de un componente junto con su explicación
+type ComponentResult struct {
+	Score  float64
+	Reason string
+}
+
+// ScoreComponent es un factor del puntaje de un ticker
+type ScoreComponent interface {
+	Name() string
+	Score(in ScoreInput) ComponentResult
+}
+
+// ScoreComponentFunc adapta una función a ScoreComponent
+type ScoreComponentFunc struct {
+	ComponentName string
+	Fn            func(in ScoreInput) ComponentResult
+}
+
+// Name implementa ScoreComponent
+func (f ScoreComponentFunc) Name() string { return f.ComponentName }
+
+// Score implementa ScoreComponent
+func (f ScoreComponentFunc) Score(in ScoreInput) ComponentResult { return f.Fn(in) }
+
+var (
+	componentsMu    sync.RWMutex
+	scoreComponents = map[string]ScoreComponent{}
+)
+
+// RegisterScoreComponent registra un componente para que las estrategias
+// puedan referenciarlo por nombre
+func RegisterScoreComponent(component ScoreComponent) {
+	componentsMu.Lock()
+	defer componentsMu.Unlock()
+	scoreComponents[component.Name()] = component
+}
+
+// GetScoreComponent obtiene un componente registrado
+func GetScoreComponent(name string) (ScoreComponent, bool) {
+	componentsMu.RLock()
+	defer componentsMu.RUnlock()
+	component, exists := scoreComponents[name]
+	return component, exists
+}
+
+// Nombres de los componentes incluidos
+const (
+	ComponentPriceTarget      = "price_target"
+	ComponentRatingMove       = "rating_move"
+	ComponentBrokerReputation = "broker_reputation"
+	ComponentRecency          = "recency"
+	ComponentConsensus        = "consensus"
+	ComponentConsensusTarget  = "consensus_target"
+)
+
+func init() {
+	RegisterScoreComponent(ScoreComponentFunc{ComponentPriceTarget, priceTargetScore})
+	RegisterScoreComponent(ScoreComponentFunc{ComponentRatingMove, ratingMoveScore})
+	RegisterScoreComponent(ScoreComponentFunc{ComponentBrokerReputation, brokerReputationScore})
+	RegisterScoreComponent(ScoreComponentFunc{ComponentRecency, recencyScore})
+	RegisterScoreComponent(ScoreComponentFunc{ComponentConsensus, consensusScore})
+	RegisterScoreComponent(ScoreComponentFunc{ComponentConsensusTarget, consensusTargetScore})
+}
+
+// priceTargetScore puntúa el cambio porcentual del precio objetivo
+func priceTargetScore(in ScoreInput) ComponentResult {
+	stock := in.Stock
+	score := calculatePriceImpact(stock, in.Config)
+	if stock.TargetTo <= 0 {
+		return ComponentResult{score, "El evento no tiene precio objetivo"}
+	}
+	pct, ok := ChangePct(stock.TargetFrom, stock.TargetTo)
+	if !ok {
+		return ComponentResult{score, fmt.Sprintf("Precio objetivo de $%.2f sin precio anterior con el que comparar", stock.TargetTo)}
+	}
+
+	band := in.Config.Price.BandFor(pct)
+	switch {
+	case pct > 0:
+		return ComponentResult{score, fmt.Sprintf("El precio objetivo sube %.1f%% (de $%.2f a $%.2f), tramo desde %g%%",
+			pct, stock.TargetFrom, stock.TargetTo, band.MinPct)}
+	case pct < 0:
+		return ComponentResult{score, fmt.Sprintf("El precio objetivo baja %.1f%% (de $%.2f a $%.2f), tramo desde %g%%",
+			-pct, stock.TargetFrom, stock.TargetTo, band.MinPct)}
+	default:
+		return ComponentResult{score, fmt.Sprintf("El precio objetivo se mantiene en $%.2f", stock.TargetTo)}
+	}
+}
+
+// ratingMoveScore puntúa la transición de calificación según la matriz
+func ratingMoveScore(in ScoreInput) ComponentResult {
+	ratings := &in.Config.Ratings
+	score := ratings.TransitionScore(in.Stock.RatingFrom, in.Stock.RatingTo)
+	from, to := ratings.Normalize(in.Stock.RatingFrom), ratings.Normalize(in.Stock.RatingTo)
+	switch {
+	case to == TierNone:
+		return ComponentResult{score, "El evento no tiene calificación nueva"}
+	case from == TierUnknown || to == TierUnknown:
+		return ComponentResult{score, fmt.Sprintf("Calificación fuera de la taxonomía (%q → %q)", in.Stock.RatingFrom, in.Stock.RatingTo)}
+	case from == TierNone:
+		return ComponentResult{score, fmt.Sprintf("Inicio de cobertura con %q (%s)", in.Stock.RatingTo, to)}
+	default:
+		return ComponentResult{score, fmt.Sprintf("Calificación de %q a %q (%s → %s)", in.Stock.RatingFrom, in.Stock.RatingTo, from, to)}
+	}
+}
+
+// brokerReputationScore puntúa el peso del brokerage que emite el evento
+func brokerReputationScore(in ScoreInput) ComponentResult {
+	if in.Brokers == nil {
+		return ComponentResult{0, "Sin pesos de brokerages configurados"}
+	}
+	score := in.Brokers.GetScore(in.Stock.Brokerage)
+	if score == 0 {
+		return ComponentResult{0, fmt.Sprintf("%s no tiene peso asignado", in.Stock.Brokerage)}
+	}
+	return ComponentResult{score, fmt.Sprintf("%s tiene un peso de %g", in.Stock.Brokerage, score)}
+}
+
+// recencyWindow es el periodo en el que un evento aporta puntaje por recencia
+const recencyWindow = 30 * 24 * time.Hour
+
+// recencyScore puntúa de 1 (evento de ahora) a 0 (evento de hace 30 días o más)
+func recencyScore(in ScoreInput) ComponentResult {
+	eventTime, err := time.Parse(time.RFC3339Nano, in.Stock.Time)
+	if err != nil {
+		return ComponentResult{0, fmt.Sprintf("Fecha del evento inválida %q", in.Stock.Time)}
+	}
+	age := in.Now.Sub(eventTime)
+	if age < 0 {
+		age = 0
+	}
+	score := math.Max(0, 1-float64(age)/float64(recencyWindow))
+	return ComponentResult{score, fmt.Sprintf("El evento tiene %.1f días de antigüedad", age.Hours()/24)}
+}
+
+// consensusFor retorna el consenso del ticker, calculándolo a partir de los
+// eventos disponibles si no se proporcionó
+func consensusFor(in ScoreInput) models.StockConsensus {
+	if in.Consensus != nil {
+		return *in.Consensus
+	}
+	events := in.Events
+	if len(events) == 0 {
+		events = []models.Stock{in.Stock}
+	}
+	return BuildConsensus(in.Stock.Ticker, events, in.Config)
+}
+
+// consensusScore puntúa entre -2 y 2 el balance de subidas y bajadas de
+// calificación entre los brokerages, reducido según la dispersión de sus
+// precios objetivo
+func consensusScore(in ScoreInput) ComponentResult {
+	consensus := consensusFor(in)
+	if consensus.Brokerages == 0 {
+		return ComponentResult{0, "Sin opiniones de brokerages"}
+	}
+
+	agreement := 1 - math.Min(consensus.TargetDispersion, 1)
+	score := 2 * float64(consensus.Upgrades-consensus.Downgrades) / float64(consensus.Brokerages) * agreement
+	return ComponentResult{score, fmt.Sprintf("%d subidas, %d bajadas y %d sin cambio entre %d brokerages; dispersión de precios objetivo %.1f%%",
+		consensus.Upgrades, consensus.Downgrades, consensus.Maintained, consensus.Brokerages, consensus.TargetDispersion*100)}
+}
+
+// consensusTargetScore puntúa el cambio de la mediana de los precios objetivo
+// de los brokerages con los tramos de price
+func consensusTargetScore(in ScoreInput) ComponentResult {
+	consensus := consensusFor(in)
+	if consensus.Targets == 0 {
+		return ComponentResult{0, "Ningún brokerage publica precio objetivo"}
+	}
+
+	score := in.Config.Price.Impact(consensus.MedianPreviousTarget, consensus.MedianTarget)
+	pct, ok := ChangePct(consensus.MedianPreviousTarget, consensus.MedianTarget)
+	if !ok {
+		return ComponentResult{score, fmt.Sprintf("Mediana del precio objetivo de $%.2f entre %d brokerages, sin precios anteriores",
+			consensus.MedianTarget, consensus.Targets)}
+	}
+	return ComponentResult{score, fmt.Sprintf("Mediana del precio objetivo de $%.2f a $%.2f (%+.1f%%) entre %d brokerages",
+		consensus.MedianPreviousTarget, consensus.MedianTarget, pct, consensus.Targets)}
+}
+
+// ratingDirection retorna 1 si el evento sube la calificación, -1 si la baja y
+// 0 si la mantiene o no se puede determinar
+func ratingDirection(cfg *ScoringConfig, event models.Stock) int {
+	from := tierRank(cfg.Ratings.Normalize(event.RatingFrom))
+	to := tierRank(cfg.Ratings.Normalize(event.RatingTo))
+	if from < 0 || to < 0 || from == to {
+		return 0
+	}
+	if to > from {
+		return 1
+	}
+	return -1
+}
+
+// tierRank retorna la posición del nivel en RatingTiers o -1 si no es canónico
+func tierRank(tier RatingTier) int {
+	for i, known := range RatingTiers {
+		if tier == known {
+			return i
+		}
+	}
+	return -1
+}
+
+// WeightedComponent es un componente de una estrategia con su peso
+type WeightedComponent struct {
+	Component ScoreComponent
+	Weight    float64
+}
+
+// ScoringStrategy combina componentes ponderados en un puntaje
+type ScoringStrategy struct {
+	Name        string
+	Description string
+	Components  []WeightedComponent
+	// Bands son los tramos de recomendación ordenados de menor a mayor puntaje
+	Bands []RecommendationBand
+
+	// config es la configuración con la que se construyó la estrategia
+	config *ScoringConfig
+}
+
+// Config retorna la configuración a la que pertenece la estrategia. Puntuar
+// con ella, y no con GetScoringConfig, evita mezclar la estrategia de una
+// configuración con los parámetros de otra si se recarga entre medio.
+func (s *ScoringStrategy) Config() *ScoringConfig {
+	return s.config
+}
+
+// Uses indica si la estrategia incluye alguno de los componentes indicados
+func (s *ScoringStrategy) Uses(names ...string) bool {
+	for _, wc := range s.Components {
+		for _, name := range names {
+			if wc.Component.Name() == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Score calcula la suma ponderada de los componentes de la estrategia
+func (s *ScoringStrategy) Score(in ScoreInput) float64 {
+	score, _ := s.Explain(in)
+	return score
+}
+
+// Explain calcula el puntaje de la estrategia junto con el aporte y la razón
+// de cada componente
+func (s *ScoringStrategy) Explain(in ScoreInput) (float64, []models.ScoreBreakdown) {
+	var score float64
+	breakdown := make([]models.ScoreBreakdown, 0, len(s.Components))
+	for _, wc := range s.Components {
+		result := wc.Component.Score(in)
+		contribution := wc.Weight * result.Score
+		score += contribution
+		breakdown = append(breakdown, models.ScoreBreakdown{
+			Component:    wc.Component.Name(),
+			Value:        result.Score,
+			Weight:       wc.Weight,
+			Contribution: contribution,
+			Reason:       result.Reason,
+		})
+	}
+	return score, breakdown
+}
+
+// StrategyConfig define una estrategia en la configuración de puntajes
+type StrategyConfig struct {
+	Description string             `json:"description" yaml:"description"`
+	Components  map[string]float64 `json:"components" yaml:"components"`
+	// Bands reemplaza los tramos de recomendación generales para esta estrategia
+	Bands []RecommendationBand `json:"bands,omitempty" yaml:"bands,omitempty"`
+}
+
+// defaultStrategies retorna las estrategias por defecto. "default" suma el
+// impacto del precio objetivo, el cambio de calificación y la reputación del
+// broker con el mismo peso.
+func defaultStrategies() map[string]StrategyConfig {
+	return map[string]StrategyConfig{
+		"default": {
+			Description: "Precio objetivo, cambio de calificación y reputación del broker con el mismo peso",
+			Components: map[string]float64{
+				ComponentPriceTarget:      1,
+				ComponentRatingMove:       1,
+				ComponentBrokerReputation: 1,
+			},
+		},
+		"momentum": {
+			Description: "Favorece subidas de precio objetivo y eventos recientes",
+			Components: map[string]float64{
+				ComponentPriceTarget:      1.5,
+				ComponentRatingMove:       1,
+				ComponentBrokerReputation: 0.5,
+				ComponentRecency:          2,
+			},
+		},
+		"consensus": {
+			Description: "Favorece el acuerdo entre brokerages sobre la opinión de uno solo",
+			Components: map[string]float64{
+				ComponentPriceTarget:      0.5,
+				ComponentRatingMove:       0.5,
+				ComponentBrokerReputation: 0.5,
+				ComponentConsensus:        2,
+				ComponentConsensusTarget:  1,
+			},
+		},
+	}
+}
+
+// buildStrategies valida las estrategias configuradas y las construye. Las
+// estrategias sin tramos propios usan defaultBands.
+func buildStrategies(configs map[string]StrategyConfig, defaultBands []RecommendationBand) (map[string]*ScoringStrategy, error) {
+	strategies := make(map[string]*ScoringStrategy, len(configs))
+	for name, cfg := range configs {
+		if len(cfg.Components) == 0 {
+			return nil, fmt.Errorf("la estrategia %q no tiene componentes", name)
+		}
+
+		// Ordenar por nombre para que el resultado sea determinista
+		names := make([]string, 0, len(cfg.Components))
+		for componentName := range cfg.Components {
+			names = append(names, componentName)
+		}
+		sort.Strings(names)
+
+		strategy := &ScoringStrategy{Name: name, Description: cfg.Description}
+		for _, componentName := range names {
+			component, exists := GetScoreComponent(componentName)
+			if !exists {
+				return nil, fmt.Errorf("componente desconocido %q en la estrategia %q", componentName, name)
+			}
+			weight := cfg.Components[componentName]
+			if math.IsNaN(weight) || math.IsInf(weight, 0) {
+				return nil, fmt.Errorf("peso inválido para %q en la estrategia %q", componentName, name)
+			}
+			strategy.Components = append(strategy.Components, WeightedComponent{Component: component, Weight: weight})
+		}
+
+		bands := defaultBands
+		if len(cfg.Bands) > 0 {
+			var err error
+			if bands, err = prepareBands(cfg.Bands); err != nil {
+				return nil, fmt.Errorf("estrategia %q: %v", name, err)
+			}
+		}
+		strategy.Bands = bands
+		strategies[name] = strategy
+	}
+	return strategies, nil
+}
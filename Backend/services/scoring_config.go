@@ -0,0 +1,175 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ScoringConfig contiene los parámetros configurables del motor de puntajes.
+// Se carga desde el archivo indicado en SCORING_CONFIG_PATH (JSON o YAML);
+// lo que no aparece en el archivo conserva los valores por defecto.
+type ScoringConfig struct {
+	Ratings RatingConfig `json:"ratings" yaml:"ratings"`
+	Price   PriceConfig  `json:"price" yaml:"price"`
+	Decay   DecayConfig  `json:"decay" yaml:"decay"`
+	// Strategies son las estrategias disponibles por nombre; las del archivo
+	// se agregan a las por defecto o reemplazan la del mismo nombre
+	Strategies map[string]StrategyConfig `json:"strategies" yaml:"strategies"`
+	// DefaultStrategy es la estrategia usada cuando no se indica ninguna
+	DefaultStrategy string `json:"default_strategy" yaml:"default_strategy"`
+	// Bands son los tramos de recomendación de las estrategias sin tramos propios
+	Bands []RecommendationBand `json:"bands" yaml:"bands"`
+	// LabelSets traducen las etiquetas de los tramos por región o idioma:
+	// nombre del conjunto -> key del tramo -> etiqueta
+	LabelSets map[string]map[string]string `json:"label_sets" yaml:"label_sets"`
+
+	// strategies son las estrategias construidas a partir de Strategies
+	strategies map[string]*ScoringStrategy
+}
+
+var scoringConfig atomic.Pointer[ScoringConfig]
+
+// DefaultScoringConfig retorna la configuración por defecto del motor de puntajes
+func DefaultScoringConfig() *ScoringConfig {
+	cfg := &ScoringConfig{
+		Ratings:         defaultRatingConfig(),
+		Price:           defaultPriceConfig(),
+		Decay:           defaultDecayConfig(),
+		Strategies:      defaultStrategies(),
+		DefaultStrategy: "default",
+		Bands:           defaultRecommendationBands(),
+	}
+	if err := cfg.prepare(); err != nil {
+		// La configuración por defecto siempre debe ser válida
+		panic(err)
+	}
+	return cfg
+}
+
+// LoadScoringConfig lee y valida la configuración del archivo indicado.
+// Las claves del archivo sobrescriben las de la configuración por defecto;
+// ratings.aliases, si aparece, reemplaza a los aliases por defecto completos.
+func LoadScoringConfig(path string) (*ScoringConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error al leer la configuración de puntajes: %v", err)
+	}
+
+	cfg := DefaultScoringConfig()
+	// Los aliases del archivo reemplazan por completo a los por defecto: al
+	// combinarlos, una etiqueta movida de nivel quedaría en los dos
+	defaultAliases := cfg.Ratings.Aliases
+	cfg.Ratings.Aliases = nil
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(cfg)
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(cfg)
+	default:
+		return nil, fmt.Errorf("extensión de configuración no soportada %q", filepath.Ext(path))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error al interpretar la configuración de puntajes: %v", err)
+	}
+	if cfg.Ratings.Aliases == nil {
+		cfg.Ratings.Aliases = defaultAliases
+	}
+
+	if err := cfg.prepare(); err != nil {
+		return nil, fmt.Errorf("configuración de puntajes inválida: %v", err)
+	}
+	return cfg, nil
+}
+
+// prepare valida la configuración y construye sus índices
+func (c *ScoringConfig) prepare() error {
+	if err := c.Ratings.prepare(); err != nil {
+		return err
+	}
+	if err := c.Price.prepare(); err != nil {
+		return err
+	}
+	if err := c.Decay.prepare(); err != nil {
+		return err
+	}
+
+	bands, err := prepareBands(c.Bands)
+	if err != nil {
+		return err
+	}
+	c.Bands = bands
+
+	strategies, err := buildStrategies(c.Strategies, bands)
+	if err != nil {
+		return err
+	}
+	for _, strategy := range strategies {
+		strategy.config = c
+	}
+	if err := validateLabelSets(c.LabelSets, strategies); err != nil {
+		return err
+	}
+	if _, exists := strategies[c.DefaultStrategy]; !exists {
+		return fmt.Errorf("la estrategia por defecto %q no existe", c.DefaultStrategy)
+	}
+	c.strategies = strategies
+	return nil
+}
+
+// Strategy retorna la estrategia con el nombre indicado; un nombre vacío
+// retorna la estrategia por defecto
+func (c *ScoringConfig) Strategy(name string) (*ScoringStrategy, bool) {
+	if name == "" {
+		name = c.DefaultStrategy
+	}
+	strategy, exists := c.strategies[name]
+	return strategy, exists
+}
+
+// StrategyNames retorna los nombres de las estrategias ordenados
+func (c *ScoringConfig) StrategyNames() []string {
+	names := make([]string, 0, len(c.strategies))
+	for name := range c.strategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ReloadScoringConfig vuelve a cargar la configuración desde SCORING_CONFIG_PATH.
+// Si la variable no está definida se usa la configuración por defecto. Si el
+// archivo es inválido se mantiene la configuración actual.
+func ReloadScoringConfig() (*ScoringConfig, error) {
+	cfg := DefaultScoringConfig()
+	if path := os.Getenv("SCORING_CONFIG_PATH"); path != "" {
+		loaded, err := LoadScoringConfig(path)
+		if err != nil {
+			return nil, err
+		}
+		cfg = loaded
+	}
+	scoringConfig.Store(cfg)
+	return cfg, nil
+}
+
+// GetScoringConfig retorna la configuración vigente del motor de puntajes
+func GetScoringConfig() *ScoringConfig {
+	if cfg := scoringConfig.Load(); cfg != nil {
+		return cfg
+	}
+	cfg := DefaultScoringConfig()
+	scoringConfig.CompareAndSwap(nil, cfg)
+	return scoringConfig.Load()
+}
@@ -1,49 +1,14 @@
 package services
 
 import (
+	"fmt"
 	"sort"
 	"strings"
+	"time"
 
 	"Backend/models"
 )
 
-type Rating string
-
-const (
-	Sell           Rating = "Sell"
-	MarketPerforme Rating = "Market Perform"
-	Buy            Rating = "Buy"
-	Neutral        Rating = "Neutral"
-	Underweight    Rating = "Underweight"
-	Overweight     Rating = "Overweight"
-)
-
-const (
-	PriceIncreaseScore                  = 3
-	LargePriceIncreaseBonus             = 2
-	SellToBuyScore                      = 3
-	NeutralToBuyScore                   = 2
-	SellToNeutralScore                  = 1
-	MarketPerformeToBuyScore            = 3
-	MarketPerformeToNeutralScore        = 2
-	MarketPerformeToSellScore           = 1
-	MarketPerformeToMarketPerformeScore = 3
-	MarketPerformeToUnderweightScore    = 2
-	MarketPerformeToOverweightScore     = 1
-	UnderweightToBuyScore               = 3
-	UnderweightToNeutralScore           = 2
-	UnderweightToSellScore              = 1
-	UnderweightToMarketPerformeScore    = 3
-	UnderweightToUnderweightScore       = 2
-	UnderweightToOverweightScore        = 1
-	OverweightToBuyScore                = 3
-	OverweightToNeutralScore            = 2
-	OverweightToSellScore               = 1
-	OverweightToMarketPerformeScore     = 3
-	OverweightToUnderweightScore        = 2
-	OverweightToOverweightScore         = 1
-)
-
 type BrokerScorer interface {
 	GetScore(brokerage string) float64
 }
@@ -68,90 +33,144 @@ func NewDefaultBrokerScorer(topBrokers map[string]float64) *DefaultBrokerScorer
 	}
 }
 
-func calculatePriceImpact(stock models.Stock) float64 {
-	priceIncrease := stock.TargetTo - stock.TargetFrom
-	if priceIncrease > 0 {
-		if priceIncrease > 10 {
-			return PriceIncreaseScore + LargePriceIncreaseBonus
+// calculatePriceImpact puntúa el cambio porcentual del precio objetivo según
+// los tramos de la configuración
+func calculatePriceImpact(stock models.Stock, cfg *ScoringConfig) float64 {
+	return cfg.Price.Impact(stock.TargetFrom, stock.TargetTo)
+}
+
+// CalculateStockScore puntúa un stock con la estrategia indicada
+func CalculateStockScore(stock models.Stock, strategy *ScoringStrategy, scorer BrokerScorer) float64 {
+	return ExplainTicker([]models.Stock{stock}, nil, strategy, scorer, time.Now()).Score
+}
+
+// ExplainTicker puntúa un ticker a partir de sus eventos y retorna la
+// recomendación con el aporte de cada componente.
+//
+// Sin agregación se puntúa solo el evento más reciente. Con agregación cada
+// evento se puntúa por separado y se promedian ponderados por su decaimiento.
+// En ambos casos el resultado se multiplica por el decaimiento del evento más
+// reciente, de modo que un ticker sin eventos recientes pierde puntaje. events
+// debe contener al menos un evento; consensus puede ser nil.
+func ExplainTicker(events []models.Stock, consensus *models.StockConsensus, strategy *ScoringStrategy, scorer BrokerScorer, now time.Time) models.StockRecommendation {
+	cfg := strategy.Config()
+
+	// Descartar eventos repetidos y ordenar del más reciente al más antiguo
+	unique := make([]models.Stock, 0, len(events))
+	seen := make(map[string]bool, len(events))
+	for _, event := range events {
+		if !seen[event.Time] {
+			seen[event.Time] = true
+			unique = append(unique, event)
 		}
-		return PriceIncreaseScore
 	}
-	return 0
-}
+	events = unique
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].Time != events[j].Time {
+			return events[i].Time > events[j].Time
+		}
+		return events[i].ID > events[j].ID
+	})
+	latest := events[0]
+	if !cfg.Decay.Aggregate {
+		events = events[:1]
+	}
 
-func calculateRatingImpact(stock models.Stock) float64 {
-	if stock.RatingFrom == string(Sell) && stock.RatingTo == string(Buy) {
-		return SellToBuyScore
-	} else if stock.RatingFrom == string(Neutral) && stock.RatingTo == string(Buy) {
-		return NeutralToBuyScore
-	} else if stock.RatingFrom == string(Sell) && stock.RatingTo == string(Neutral) {
-		return SellToNeutralScore
-	} else if stock.RatingFrom == string(MarketPerforme) && stock.RatingTo == string(Buy) {
-		return MarketPerformeToBuyScore
-	} else if stock.RatingFrom == string(MarketPerforme) && stock.RatingTo == string(Neutral) {
-		return MarketPerformeToNeutralScore
-	} else if stock.RatingFrom == string(MarketPerforme) && stock.RatingTo == string(Sell) {
-		return MarketPerformeToSellScore
-	} else if stock.RatingFrom == string(MarketPerforme) && stock.RatingTo == string(MarketPerforme) {
-		return MarketPerformeToMarketPerformeScore
-	} else if stock.RatingFrom == string(MarketPerforme) && stock.RatingTo == string(Underweight) {
-		return MarketPerformeToUnderweightScore
-	} else if stock.RatingFrom == string(MarketPerforme) && stock.RatingTo == string(Overweight) {
-		return MarketPerformeToOverweightScore
-	} else if stock.RatingFrom == string(Underweight) && stock.RatingTo == string(Buy) {
-		return UnderweightToBuyScore
-	} else if stock.RatingFrom == string(Underweight) && stock.RatingTo == string(Neutral) {
-		return UnderweightToNeutralScore
-	} else if stock.RatingFrom == string(Underweight) && stock.RatingTo == string(Sell) {
-		return UnderweightToSellScore
-	} else if stock.RatingFrom == string(Underweight) && stock.RatingTo == string(MarketPerforme) {
-		return UnderweightToMarketPerformeScore
-	} else if stock.RatingFrom == string(Underweight) && stock.RatingTo == string(Underweight) {
-		return UnderweightToUnderweightScore
-	} else if stock.RatingFrom == string(Underweight) && stock.RatingTo == string(Overweight) {
-		return UnderweightToOverweightScore
-	} else if stock.RatingFrom == string(Overweight) && stock.RatingTo == string(Buy) {
-		return OverweightToBuyScore
-	} else if stock.RatingFrom == string(Overweight) && stock.RatingTo == string(Neutral) {
-		return OverweightToNeutralScore
-	} else if stock.RatingFrom == string(Overweight) && stock.RatingTo == string(Sell) {
-		return OverweightToSellScore
-	} else if stock.RatingFrom == string(Overweight) && stock.RatingTo == string(MarketPerforme) {
-		return OverweightToMarketPerformeScore
-	} else if stock.RatingFrom == string(Overweight) && stock.RatingTo == string(Underweight) {
-		return OverweightToUnderweightScore
-	} else if stock.RatingFrom == string(Overweight) && stock.RatingTo == string(Overweight) {
-		return OverweightToOverweightScore
+	var totalWeight float64
+	var breakdown []models.ScoreBreakdown
+	for i, event := range events {
+		in := ScoreInput{Stock: event, Consensus: consensus, Brokers: scorer, Config: cfg, Now: now}
+		if cfg.Decay.Aggregate {
+			in.Events = events
+		}
+		_, items := strategy.Explain(in)
+
+		weight := cfg.Decay.Factor(event.Time, now)
+		totalWeight += weight
+		if i == 0 {
+			breakdown = items
+			for j := range breakdown {
+				breakdown[j].Value *= weight
+				breakdown[j].Contribution *= weight
+			}
+			continue
+		}
+		for j := range items {
+			breakdown[j].Value += weight * items[j].Value
+			breakdown[j].Contribution += weight * items[j].Contribution
+		}
+	}
+
+	factor := cfg.Decay.Factor(latest.Time, now)
+	var score float64
+	for j := range breakdown {
+		if totalWeight > 0 {
+			breakdown[j].Value /= totalWeight
+			breakdown[j].Contribution /= totalWeight
+		}
+		breakdown[j].Contribution *= factor
+		if len(events) > 1 {
+			breakdown[j].Reason += fmt.Sprintf(" (evento más reciente; promedio ponderado de %d eventos)", len(events))
+		}
+		score += breakdown[j].Contribution
+	}
+
+	band := strategy.Classify(score)
+	return models.StockRecommendation{
+		Stock:             latest,
+		Score:             score,
+		Recommendation:    band.Label,
+		RecommendationKey: band.Key,
+		Breakdown:         breakdown,
+		DecayFactor:       factor,
+		EventCount:        len(events),
+		Consensus:         consensus,
 	}
-	return 0
 }
 
-func CalculateStockScore(stock models.Stock, scorer BrokerScorer) float64 {
-	return calculatePriceImpact(stock) + calculateRatingImpact(stock) + scorer.GetScore(stock.Brokerage)
+// CalculateStockRecommendations puntúa los stocks con la estrategia indicada y
+// los retorna ordenados del puntaje más alto al más bajo. opinions es la última
+// nota de cada brokerage por ticker y se usa para el consenso; puede ser nil.
+func CalculateStockRecommendations(stocks, opinions []models.Stock, strategy *ScoringStrategy, scorer BrokerScorer) []models.StockRecommendation {
+	return CalculateStockRecommendationsAt(stocks, opinions, strategy, scorer, time.Now())
+}
+
+// LocalizeRecommendations reemplaza las etiquetas de las recomendaciones por
+// las de los tramos indicados, buscándolas por key
+func LocalizeRecommendations(recommendations []models.StockRecommendation, bands []RecommendationBand) {
+	labels := make(map[string]string, len(bands))
+	for _, band := range bands {
+		labels[band.Key] = band.Label
+	}
+	for i := range recommendations {
+		if label, exists := labels[recommendations[i].RecommendationKey]; exists {
+			recommendations[i].Recommendation = label
+		}
+	}
 }
 
-func CalculateStockRecommendations(stocks []models.Stock, scorer BrokerScorer) []models.StockRecommendation {
-	recommendations := make([]models.StockRecommendation, 0)
+// CalculateStockRecommendationsAt puntúa los stocks como si la fecha actual
+// fuera now. stocks puede contener varios eventos por ticker; se agrupan por
+// ticker.
+func CalculateStockRecommendationsAt(stocks, opinions []models.Stock, strategy *ScoringStrategy, scorer BrokerScorer, now time.Time) []models.StockRecommendation {
+	var consensus map[string]*models.StockConsensus
+	if len(opinions) > 0 {
+		consensus = BuildConsensusByTicker(opinions, strategy.Config())
+	}
 
+	var tickers []string
+	groups := make(map[string][]models.Stock)
 	for _, stock := range stocks {
-		score := CalculateStockScore(stock, scorer)
-		recommendation := ""
-
-		if score >= 7 {
-			recommendation = "Strong Buy"
-		} else if score >= 5 {
-			recommendation = "Buy"
-		} else if score >= 3 {
-			recommendation = "Hold"
-		} else {
-			recommendation = "Sell"
+		ticker := strings.ToUpper(stock.Ticker)
+		if _, exists := groups[ticker]; !exists {
+			tickers = append(tickers, ticker)
 		}
+		groups[ticker] = append(groups[ticker], stock)
+	}
 
-		recommendations = append(recommendations, models.StockRecommendation{
-			Stock:          stock,
-			Score:          score,
-			Recommendation: recommendation,
-		})
+	recommendations := make([]models.StockRecommendation, 0, len(tickers))
+	for _, ticker := range tickers {
+		recommendations = append(recommendations, ExplainTicker(groups[ticker], consensus[ticker], strategy, scorer, now))
 	}
 
 	// Ordenar por score más alto
@@ -177,3 +196,14 @@ func SanitizeInput(input string) string {
 	}, input)
 	return input
 }
+
+// SanitizeTicker limpia un ticker y lo normaliza a mayúsculas
+func SanitizeTicker(input string) string {
+	input = strings.ToUpper(strings.TrimSpace(input))
+	return strings.Map(func(r rune) rune {
+		if r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '.' {
+			return r
+		}
+		return -1
+	}, input)
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// DecayConfig define cómo pierde peso un evento con su antigüedad
+type DecayConfig struct {
+	// HalfLifeDays es la antigüedad en días a la que un evento vale la mitad;
+	// 0 desactiva el decaimiento
+	HalfLifeDays float64 `json:"half_life_days" yaml:"half_life_days"`
+	// Aggregate combina todos los eventos del ticker dentro de WindowDays en
+	// lugar de puntuar solo el más reciente
+	Aggregate bool `json:"aggregate" yaml:"aggregate"`
+	// WindowDays es la ventana de eventos que se combinan cuando Aggregate está activo
+	WindowDays float64 `json:"window_days" yaml:"window_days"`
+}
+
+// defaultDecayConfig retorna el decaimiento por defecto, desactivado: los
+// tramos de recomendación están pensados para puntajes sin decaer, y con
+// decaimiento un evento antiguo acabaría en Sell sea cual sea su contenido
+func defaultDecayConfig() DecayConfig {
+	return DecayConfig{
+		HalfLifeDays: 0,
+		Aggregate:    false,
+		WindowDays:   30,
+	}
+}
+
+// prepare valida la configuración de decaimiento
+func (d *DecayConfig) prepare() error {
+	if math.IsNaN(d.HalfLifeDays) || math.IsInf(d.HalfLifeDays, 0) || d.HalfLifeDays < 0 {
+		return fmt.Errorf("decay.half_life_days debe ser un número mayor o igual a 0")
+	}
+	if math.IsNaN(d.WindowDays) || math.IsInf(d.WindowDays, 0) || d.WindowDays <= 0 {
+		return fmt.Errorf("decay.window_days debe ser un número mayor a 0")
+	}
+	return nil
+}
+
+// Window retorna la ventana de eventos que se combinan
+func (d *DecayConfig) Window() time.Duration {
+	return time.Duration(d.WindowDays * float64(24*time.Hour))
+}
+
+// Factor retorna el peso, entre 0 y 1, de un evento con la fecha indicada.
+// Los eventos con fecha inválida o futura no pierden peso.
+func (d *DecayConfig) Factor(eventTime string, now time.Time) float64 {
+	if d.HalfLifeDays == 0 {
+		return 1
+	}
+	t, err := time.Parse(time.RFC3339Nano, eventTime)
+	if err != nil {
+		return 1
+	}
+	age := now.Sub(t)
+	if age <= 0 {
+		return 1
+	}
+	return math.Pow(0.5, age.Hours()/24/d.HalfLifeDays)
+}
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"Backend/models"
+	"Backend/repositories"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrUsernameTaken indica que ya existe un usuario con ese nombre
+	ErrUsernameTaken = errors.New("ya existe un usuario con ese nombre")
+	// ErrLastAdmin indica que la operación dejaría el sistema sin
+	// administradores activos
+	ErrLastAdmin = errors.New("no se puede quitar el último administrador activo")
+)
+
+// UserUpdate son los cambios a aplicar a un usuario; los campos nil no cambian
+type UserUpdate struct {
+	Role     *string
+	Active   *bool
+	Password *string
+}
+
+// CreateUserAccount crea un usuario activo con el rol indicado
+func CreateUserAccount(db *gorm.DB, username, password, role string) (*models.User, error) {
+	if _, err := repositories.GetUserByUsername(db, username); err == nil {
+		return nil, ErrUsernameTaken
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	hash, err := HashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+	user := &models.User{Username: username, PasswordHash: hash, Role: role, Active: true}
+	if err := repositories.CreateUser(db, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// UpdateUserAccount aplica los cambios a un usuario. Si cambia el rol, la
+// contraseña o se desactiva, se revocan sus refresh tokens para que el cambio
+// aplique cuando venza el access token actual. Si se desactiva o pierde
+// permisos también se revocan las API keys que creó, cuyos scopes pueden
+// superar los de su nuevo rol.
+func UpdateUserAccount(db *gorm.DB, id int64, update UserUpdate) (*models.User, error) {
+	user, err := repositories.GetUser(db, id)
+	if err != nil {
+		return nil, err
+	}
+
+	wasAdmin := user.Active && user.Role == models.RoleAdmin
+	revoke, revokeKeys := false, false
+	if update.Role != nil && *update.Role != user.Role {
+		for _, permission := range RolePermissions(user.Role) {
+			if !HasPermission(*update.Role, permission) {
+				revokeKeys = true
+				break
+			}
+		}
+		user.Role = *update.Role
+		revoke = true
+	}
+	if update.Active != nil && *update.Active != user.Active {
+		user.Active = *update.Active
+		revoke = revoke || !user.Active
+		revokeKeys = revokeKeys || !user.Active
+	}
+	if update.Password != nil {
+		hash, err := HashPassword(*update.Password)
+		if err != nil {
+			return nil, err
+		}
+		user.PasswordHash = hash
+		revoke = true
+	}
+
+	if wasAdmin && !(user.Active && user.Role == models.RoleAdmin) {
+		if err := ensureAnotherAdmin(db); err != nil {
+			return nil, err
+		}
+	}
+	if err := repositories.SaveUser(db, user); err != nil {
+		return nil, err
+	}
+	if revoke {
+		if err := repositories.RevokeUserRefreshTokens(db, user.ID, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+	if revokeKeys {
+		if err := repositories.RevokeUserAPIKeys(db, user.ID, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+	return user, nil
+}
+
+// DeleteUserAccount elimina un usuario y sus refresh tokens y revoca sus API keys
+func DeleteUserAccount(db *gorm.DB, id int64) error {
+	user, err := repositories.GetUser(db, id)
+	if err != nil {
+		return err
+	}
+	if user.Role == models.RoleAdmin && user.Active {
+		if err := ensureAnotherAdmin(db); err != nil {
+			return err
+		}
+	}
+	return repositories.DeleteUser(db, id)
+}
+
+// ensureAnotherAdmin retorna ErrLastAdmin si hay como mucho un administrador
+// activo
+func ensureAnotherAdmin(db *gorm.DB) error {
+	admins, err := repositories.CountActiveUsersWithRole(db, models.RoleAdmin)
+	if err != nil {
+		return err
+	}
+	if admins <= 1 {
+		return ErrLastAdmin
+	}
+	return nil
+}
@@ -2,6 +2,8 @@ package utils
 
 import (
 	"fmt"
+	"math"
+	"strconv"
 	"strings"
 )
 
@@ -11,3 +13,20 @@ func ParsePrice(price string) float64 {
 	fmt.Sscanf(price, "%f", &value)
 	return value
 }
+
+// ParsePriceStrict convierte un precio como "$1,234.50" a float64.
+// A diferencia de ParsePrice retorna error si el valor no es un número válido
+// o finito ("NaN", "Inf" y "+Infinity" los acepta strconv.ParseFloat).
+func ParsePriceStrict(price string) (float64, error) {
+	cleaned := strings.TrimSpace(price)
+	cleaned = strings.ReplaceAll(cleaned, "$", "")
+	cleaned = strings.ReplaceAll(cleaned, ",", "")
+	if cleaned == "" {
+		return 0, nil
+	}
+	value, err := strconv.ParseFloat(cleaned, 64)
+	if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, fmt.Errorf("precio inválido %q", price)
+	}
+	return value, nil
+}
@@ -1,9 +1,15 @@
 import { defineStore } from "pinia";
 import { ref } from "vue";
-import type { StockRecommendation, StockRecommendationResponse } from "@/types/StockRecommendation";
+import type {
+  RecommendationBand,
+  RecommendationBandsResponse,
+  StockRecommendation,
+  StockRecommendationResponse,
+} from "@/types/StockRecommendation";
 
 export const useStockRecommendationStore = defineStore("stockRecommendation", () => {
   const recommendations = ref<StockRecommendation[]>([]);
+  const bands = ref<RecommendationBand[]>([]);
   const errorMessage = ref<string | null>(null);
   const loading = ref(true);
 
@@ -23,7 +29,8 @@ export const useStockRecommendationStore = defineStore("stockRecommendation", ()
       recommendations.value = data.data.map((item) => ({
         ...item.stock,
         score: item.score.toFixed(2),
-        recommendation: item.recommendation
+        recommendation: item.recommendation,
+        recommendation_key: item.recommendation_key
       }));
     } catch (error) {
       console.error("Error fetching stock recommendations:", error);
@@ -33,5 +40,22 @@ export const useStockRecommendationStore = defineStore("stockRecommendation", ()
     }
   }
 
-  return { recommendations, errorMessage, loading, loadRecommendations };
+  // Tramos de recomendación de la estrategia, para dibujar la leyenda
+  async function loadBands() {
+    try {
+      const response = await fetch("http://localhost:9090/stocks/recommendations/bands");
+
+      if (!response.ok) {
+        throw new Error(`HTTP error! Status: ${response.status}`);
+      }
+
+      const data = await response.json() as RecommendationBandsResponse;
+      bands.value = data.data;
+    } catch (error) {
+      console.error("Error fetching recommendation bands:", error);
+      bands.value = [];
+    }
+  }
+
+  return { recommendations, bands, errorMessage, loading, loadRecommendations, loadBands };
 });
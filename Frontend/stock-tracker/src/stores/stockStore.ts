@@ -52,7 +52,8 @@ export const useStockStore = defineStore('stock', () => {
       loading.value = true
       errorMessage.value = null
 
-      const response = await apiFetch<ApiResponse<Stock[]>>('/stocks')
+      // La tabla filtra y pagina en el cliente, así que pide todos los registros
+      const response = await apiFetch<ApiResponse<Stock[]>>('/stocks?page_size=all')
       stocks.value = response.data
       isLoaded.value = true
     } catch (error) {
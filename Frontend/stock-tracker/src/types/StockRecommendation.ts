@@ -3,6 +3,43 @@ import type { Stock } from './stock'
 export interface StockRecommendation extends Stock {
   score: string;
   recommendation: string;
+  recommendation_key: string;
+}
+
+export interface ScoreBreakdown {
+  component: string;
+  value: number;
+  weight: number;
+  contribution: number;
+  reason: string;
+}
+
+export interface StockConsensus {
+  ticker: string;
+  brokerages: number;
+  upgrades: number;
+  downgrades: number;
+  maintained: number;
+  targets: number;
+  mean_target: number;
+  median_target: number;
+  min_target: number;
+  max_target: number;
+  target_std_dev: number;
+  target_dispersion: number;
+  median_previous_target: number;
+  last_update: string;
+}
+
+export interface RecommendationBand {
+  key: string;
+  label: string;
+  min: number | null;
+  max: number | null;
+}
+
+export interface RecommendationBandsResponse {
+  data: RecommendationBand[];
 }
 
 export interface StockRecommendationResponse {
@@ -10,5 +47,10 @@ export interface StockRecommendationResponse {
     stock: Stock;
     score: number;
     recommendation: string;
+    recommendation_key: string;
+    breakdown: ScoreBreakdown[];
+    decay_factor: number;
+    event_count: number;
+    consensus?: StockConsensus;
   }[];
 }
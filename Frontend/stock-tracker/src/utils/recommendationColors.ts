@@ -0,0 +1,13 @@
+// Clases de color por key de tramo; las etiquetas cambian según la región,
+// las keys no
+const RECOMMENDATION_COLORS: Record<string, string> = {
+  strong_buy: 'text-green-600',
+  buy: 'text-green-500',
+  hold: 'text-gray-500',
+  sell: 'text-red-600',
+  strong_sell: 'text-red-700',
+}
+
+export const recommendationColor = (key: string): string => {
+  return RECOMMENDATION_COLORS[key] ?? 'text-gray-700'
+}